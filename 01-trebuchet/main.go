@@ -1,82 +1,383 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"regexp"
 	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"aoc"
 )
 
-func part1() {
-	content, err := os.ReadFile("input.txt")
+// Vocabulary maps the spellings of digit tokens to their values.
+type Vocabulary map[string]int
+
+// vocabularies are the built-in vocabularies. Part 1 reads digits only,
+// part 2 reads nonzero and english, so a 0 is not a digit there.
+var vocabularies = map[string]Vocabulary{
+	"digits": {
+		"0": 0, "1": 1, "2": 2, "3": 3, "4": 4,
+		"5": 5, "6": 6, "7": 7, "8": 8, "9": 9,
+	},
+	"nonzero": {
+		"1": 1, "2": 2, "3": 3, "4": 4,
+		"5": 5, "6": 6, "7": 7, "8": 8, "9": 9,
+	},
+	"english": {
+		"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9,
+	},
+}
+
+// vocabularyFile is the JSON form of a vocabulary, e.g.
+//
+//	{"include": ["digits"], "tokens": {"eins": 1, "zwei": 2, "drei": 3}}
+//
+// where include names built-in vocabularies to start from.
+type vocabularyFile struct {
+	Include []string   `json:"include"`
+	Tokens  Vocabulary `json:"tokens"`
+}
+
+// LoadVocabulary returns the built-in vocabulary called name, or else
+// reads one from the JSON file name.
+func LoadVocabulary(name string) (Vocabulary, error) {
+	if v, ok := vocabularies[name]; ok {
+		return v, nil
+	}
+
+	data, err := os.ReadFile(name)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var file vocabularyFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	v := Vocabulary{}
+	for _, include := range file.Include {
+		builtin, ok := vocabularies[include]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown vocabulary %q", name, include)
+		}
+		for token, value := range builtin {
+			v[token] = value
+		}
+	}
+	for token, value := range file.Tokens {
+		if token == "" {
+			return nil, fmt.Errorf("%s: empty token", name)
+		}
+		v[token] = value
+	}
+	if len(v) == 0 {
+		return nil, fmt.Errorf("%s: no tokens", name)
+	}
+	return v, nil
+}
+
+// Matcher finds every digit token in a line in one pass, overlapping
+// ones included, with an Aho-Corasick automaton over the tokens.
+type Matcher struct {
+	next [][256]int32
+	// out lists the tokens ending at each state, longest first.
+	out [][]token
+}
+
+type token struct {
+	value, length int
+}
+
+func NewMatcher(vocab ...Vocabulary) *Matcher {
+	m := &Matcher{next: make([][256]int32, 1), out: make([][]token, 1)}
+
+	// build the trie, with 0 standing for "no edge" until the links are in
+	for _, v := range vocab {
+		for word, value := range v {
+			state := int32(0)
+			for i := 0; i < len(word); i++ {
+				if m.next[state][word[i]] == 0 {
+					m.next = append(m.next, [256]int32{})
+					m.out = append(m.out, nil)
+					m.next[state][word[i]] = int32(len(m.next) - 1)
+				}
+				state = m.next[state][word[i]]
+			}
+			m.out[state] = []token{{value, len(word)}}
+		}
+	}
+
+	// turn the trie into a complete transition table, breadth first so a
+	// state's failure link is finished before the state itself
+	fail := make([]int32, len(m.next))
+	var queue []int32
+	for b := range m.next[0] {
+		if s := m.next[0][b]; s != 0 {
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		m.out[state] = append(m.out[state], m.out[fail[state]]...)
+		for b := range m.next[state] {
+			if s := m.next[state][b]; s != 0 {
+				fail[s] = m.next[fail[state]][b]
+				queue = append(queue, s)
+			} else {
+				m.next[state][b] = m.next[fail[state]][b]
+			}
+		}
+	}
+	return m
+}
+
+// Match is a token found at byte offset Pos of a line.
+type Match struct {
+	Pos, Len, Value int
+}
+
+// Line is what a matcher finds in one line: every token in the order
+// they end, and the first and last of them by position.
+type Line struct {
+	Tokens      []Match
+	First, Last Match
+}
+
+func (l Line) Value() int {
+	return 10*l.First.Value + l.Last.Value
+}
+
+// lineScanner follows the automaton through one line, keeping the
+// first and last matches. Two matches at the same position, such as "1"
+// and "10" in a custom vocabulary, are told apart by keeping the longer.
+type lineScanner struct {
+	m     *Matcher
+	state int32
+	pos   int
+	found bool
+	line  Line
+	// keep records every match in line.Tokens
+	keep bool
+}
+
+func (s *lineScanner) step(b byte) {
+	s.state = s.m.next[s.state][b]
+	s.pos++
+	for _, t := range s.m.out[s.state] {
+		match := Match{s.pos - t.length, t.length, t.value}
+		first, last := &s.line.First, &s.line.Last
+		if !s.found || match.Pos < first.Pos || match.Pos == first.Pos && match.Len > first.Len {
+			*first = match
+		}
+		if !s.found || match.Pos > last.Pos || match.Pos == last.Pos && match.Len > last.Len {
+			*last = match
+		}
+		if s.keep {
+			s.line.Tokens = append(s.line.Tokens, match)
+		}
+		s.found = true
+	}
+}
+
+func (s *lineScanner) reset() {
+	*s = lineScanner{m: s.m, keep: s.keep}
+}
+
+// Scan returns the tokens found in line, and false if there are none.
+func (m *Matcher) Scan(line string) (Line, bool) {
+	s := lineScanner{m: m, keep: true}
+	for i := 0; i < len(line); i++ {
+		s.step(line[i])
 	}
+	return s.line, s.found
+}
 
-	lines := strings.Split(string(content), "\n")
-	r := regexp.MustCompile("[^0-9]+")
+// Calibrate returns the calibration value of a line, and false if it
+// has no digits.
+func (m *Matcher) Calibrate(line string) (int, bool) {
+	s := lineScanner{m: m}
+	for i := 0; i < len(line); i++ {
+		s.step(line[i])
+	}
+	return s.line.Value(), s.found
+}
 
+// Sum adds up the calibration values of the lines read from r a byte at
+// a time, so neither the input nor any one line is held in memory. Blank
+// lines are skipped and CRLF line endings are accepted.
+func (m *Matcher) Sum(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	s := lineScanner{m: m}
+	sum, line, blank := 0, 1, true
+	for {
+		b, err := br.ReadByte()
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if err == io.EOF || b == '\n' {
+			if !blank {
+				if !s.found {
+					return 0, fmt.Errorf("line %d has no digits", line)
+				}
+				sum += s.line.Value()
+			}
+			if err == io.EOF {
+				return sum, nil
+			}
+			s.reset()
+			line, blank = line+1, true
+			continue
+		}
+		if b != '\r' {
+			s.step(b)
+			blank = false
+		}
+	}
+}
+
+func solve(lines []string, m *Matcher) int {
 	sum := 0
 	for _, line := range lines {
-		digits := r.ReplaceAllString(line, "")
-		if len(digits) == 0 {
+		value, ok := m.Calibrate(line)
+		if !ok {
 			panic("line has no digits: " + line)
 		}
-		tens, ones := digits[0], digits[len(digits)-1]
-		sum += 10*int(tens-'0') + int(ones-'0')
-	}
-	println(sum)
-}
-
-func part2() {
-	digitMap := map[string]int{
-		"one":   1,
-		"two":   2,
-		"three": 3,
-		"four":  4,
-		"five":  5,
-		"six":   6,
-		"seven": 7,
-		"eight": 8,
-		"nine":  9,
-		"1":     1,
-		"2":     2,
-		"3":     3,
-		"4":     4,
-		"5":     5,
-		"6":     6,
-		"7":     7,
-		"8":     8,
-		"9":     9,
-	}
-
-	content, err := os.ReadFile("input.txt")
+		sum += value
+	}
+	return sum
+}
+
+func stream(r io.Reader, m *Matcher) int {
+	sum, err := m.Sum(r)
 	if err != nil {
 		panic(err)
 	}
+	return sum
+}
 
-	sum := 0
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		tens, ones := 0, 0
-		tensIndex, onesIndex := len(line), -1
-		for k, v := range digitMap {
-			i := strings.Index(line, k)
-			if i != -1 && i < tensIndex {
-				tensIndex = i
-				tens = v
-			}
-			j := strings.LastIndex(line, k)
-			if j != -1 && j > onesIndex {
-				onesIndex = j
-				ones = v
+var vocab = flag.String("vocab", "", "decode every part with this vocabulary, built-in or a JSON `file`")
+
+var (
+	digitMatcher = NewMatcher(vocabularies["digits"])
+	wordMatcher  = NewMatcher(vocabularies["nonzero"], vocabularies["english"])
+
+	customMatcher = sync.OnceValues(func() (*Matcher, error) {
+		v, err := LoadVocabulary(*vocab)
+		if err != nil {
+			return nil, err
+		}
+		return NewMatcher(v), nil
+	})
+)
+
+// matcher returns the matcher for -vocab if it was given, or else the
+// part's own.
+func matcher(builtin *Matcher) *Matcher {
+	if *vocab == "" {
+		return builtin
+	}
+	m, err := customMatcher()
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func part1(lines []string) any {
+	return solve(lines, matcher(digitMatcher))
+}
+
+func part2(lines []string) any {
+	return solve(lines, matcher(wordMatcher))
+}
+
+// report lists, for every line, the tokens found with their columns, the
+// first and last of them and the calibration value. Lines with no digits
+// are marked and left out of the total rather than stopping the report.
+func report(w io.Writer, part int, lines []string) error {
+	m := matcher(digitMatcher)
+	if part == 2 {
+		m = matcher(wordMatcher)
+	}
+	format := func(line string, t Match) string {
+		return fmt.Sprintf("%s@%d", line[t.Pos:t.Pos+t.Len], t.Pos+1)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tVALUE\tFIRST\tLAST\tTOKENS")
+	sum, missing := 0, 0
+	for i, line := range lines {
+		l, ok := m.Scan(line)
+		if !ok {
+			fmt.Fprintf(tw, "%d\t-\t-\t-\terror: no digits in %q\n", i+1, line)
+			missing++
+			continue
+		}
+		tokens := make([]string, len(l.Tokens))
+		for j, t := range l.Tokens {
+			tokens[j] = format(line, t)
+		}
+		fmt.Fprintf(
+			tw, "%d\t%d\t%s\t%s\t%s\n",
+			i+1, l.Value(), format(line, l.First), format(line, l.Last), strings.Join(tokens, " "),
+		)
+		sum += l.Value()
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\n", sum)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d lines have no digits", missing, len(lines))
+	}
+	return nil
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	if *vocab != "" {
+		// any text may hold tokens of a custom vocabulary
+		m, err := customMatcher()
+		if err != nil {
+			return []aoc.Diagnostic{aoc.NewDiagnostic(1, 1, "%v", err)}
+		}
+		for i, line := range lines {
+			if _, ok := m.Calibrate(line); !ok {
+				diags = append(diags, aoc.NewDiagnostic(i+1, 1, "line has no tokens"))
 			}
 		}
-		sum += 10*tens + ones
+		return diags
+	}
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.Chars(aoc.Lower+aoc.Digits, "letter or digit")
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if s.Ok() && strings.IndexAny(line, aoc.Digits) == -1 {
+			diags = append(diags, aoc.NewDiagnostic(i+1, 1, "line has no digits"))
+		}
 	}
-	println(sum)
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      1,
+		Part1:    part1,
+		Part2:    part2,
+		Stream1:  func(r io.Reader) any { return stream(r, matcher(digitMatcher)) },
+		Stream2:  func(r io.Reader) any { return stream(r, matcher(wordMatcher)) },
+		Validate: validate,
+		Report:   report,
+	})
 }
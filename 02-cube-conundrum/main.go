@@ -1,105 +1,273 @@
 package main
 
 import (
-	"os"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+
+	"aoc"
 )
 
-func part1() {
-	content, err := os.ReadFile("input.txt")
-	if err != nil {
-		panic(err)
-	}
+// Draw is one handful of cubes shown from the bag, by color.
+type Draw map[string]int
 
-	lines := strings.Split(string(content), "\n")
-	availableCubes := map[string]int{
-		"red":   12,
-		"green": 13,
-		"blue":  14,
-	}
+// Bag is the number of cubes of each color in the bag.
+type Bag map[string]int
 
-	sum := 0
-	for _, line := range lines {
-		splits := strings.Split(line, ":")
-		prefix, game := splits[0], splits[1]
-		id, err := strconv.Atoi(strings.Split(prefix, " ")[1])
+// ParseBag parses the contents of a bag such as "12 red, 13 green, 14
+// blue", or the same with a color per line.
+func ParseBag(s string) (Bag, error) {
+	bag := Bag{}
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		num, color, ok := strings.Cut(strings.TrimSpace(field), " ")
+		if !ok {
+			return nil, fmt.Errorf("expected count and color in %q", field)
+		}
+		n, err := strconv.Atoi(num)
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+		if _, ok := bag[color]; ok {
+			return nil, fmt.Errorf("color %s given twice", color)
 		}
+		bag[color] = n
+	}
+	if len(bag) == 0 {
+		return nil, errors.New("empty bag")
+	}
+	return bag, nil
+}
+
+// Colors returns the colors in the bag, sorted.
+func (b Bag) Colors() []string {
+	colors := make([]string, 0, len(b))
+	for color := range b {
+		colors = append(colors, color)
+	}
+	sort.Strings(colors)
+	return colors
+}
+
+// Power is the product of the number of cubes of each of colors.
+func (b Bag) Power(colors ...string) int {
+	power := 1
+	for _, color := range colors {
+		power *= b[color]
+	}
+	return power
+}
 
-		sets := strings.Split(game, ";")
-		ok := true
-		for _, set := range sets {
+type Game struct {
+	ID    int
+	Draws []Draw
+}
+
+// ParseGame parses a line such as "Game 1: 3 blue, 4 red; 1 red, 2 green".
+func ParseGame(line string) (Game, error) {
+	prefix, draws, ok := strings.Cut(line, ":")
+	if !ok {
+		return Game{}, fmt.Errorf("missing ':' in %q", line)
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(prefix, "Game "))
+	if err != nil {
+		return Game{}, err
+	}
+
+	game := Game{ID: id}
+	for _, set := range strings.Split(draws, ";") {
+		draw := Draw{}
+		for _, cubes := range strings.Split(set, ",") {
+			num, color, ok := strings.Cut(strings.TrimSpace(cubes), " ")
 			if !ok {
-				break
+				return Game{}, fmt.Errorf("game %d: expected count and color in %q", id, cubes)
 			}
-			cubes := strings.Split(set, ",")
-			for _, cube := range cubes {
-				splits = strings.Split(strings.TrimSpace(cube), " ")
-				num, err := strconv.Atoi(strings.TrimSpace(splits[0]))
-				if err != nil {
-					panic(err)
-				}
-				color := strings.TrimSpace(splits[1])
-				if num > availableCubes[color] {
-					ok = false
-					break
-				}
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return Game{}, fmt.Errorf("game %d: %v", id, err)
 			}
+			draw[color] += n
 		}
+		game.Draws = append(game.Draws, draw)
+	}
+	return game, nil
+}
 
-		if ok {
-			sum += id
-		}
+// Max is the most cubes of color shown in any one draw.
+func (g Game) Max(color string) int {
+	most := 0
+	for _, draw := range g.Draws {
+		most = max(most, draw[color])
 	}
+	return most
+}
 
-	println(sum)
+// MinimumBag is the smallest bag that could have given every draw.
+func (g Game) MinimumBag() Bag {
+	bag := Bag{}
+	for _, draw := range g.Draws {
+		for color, n := range draw {
+			bag[color] = max(bag[color], n)
+		}
+	}
+	return bag
 }
 
-func part2() {
-	content, err := os.ReadFile("input.txt")
-	if err != nil {
-		panic(err)
+// FeasibleWith reports whether every draw could have come from bag.
+func (g Game) FeasibleWith(bag Bag) bool {
+	for color, n := range g.MinimumBag() {
+		if n > bag[color] {
+			return false
+		}
 	}
+	return true
+}
 
-	lines := strings.Split(string(content), "\n")
+// Games is a list of games to be queried.
+type Games []Game
 
-	powers := 0
-	for _, line := range lines {
-		splits := strings.Split(line, ":")
-		game := splits[1]
-		sets := strings.Split(game, ";")
-		cubeMap := map[string]int{
-			"red":   0,
-			"green": 0,
-			"blue":  0,
+func ParseGames(lines []string) (Games, error) {
+	games := make(Games, len(lines))
+	for i, line := range lines {
+		game, err := ParseGame(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		games[i] = game
+	}
+	return games, nil
+}
+
+// Where returns the games for which keep is true.
+func (gs Games) Where(keep func(Game) bool) Games {
+	var kept Games
+	for _, g := range gs {
+		if keep(g) {
+			kept = append(kept, g)
 		}
-		for _, set := range sets {
-			cubes := strings.Split(set, ",")
-			for _, cube := range cubes {
-				splits = strings.Split(strings.TrimSpace(cube), " ")
-				num, err := strconv.Atoi(strings.TrimSpace(splits[0]))
-				if err != nil {
-					panic(err)
+	}
+	return kept
+}
+
+// FeasibleWith returns the games that could have been played with bag.
+func (gs Games) FeasibleWith(bag Bag) Games {
+	return gs.Where(func(g Game) bool { return g.FeasibleWith(bag) })
+}
+
+// Exceeding returns the games in which some draw showed more than n
+// cubes of color.
+func (gs Games) Exceeding(color string, n int) Games {
+	return gs.Where(func(g Game) bool { return g.Max(color) > n })
+}
+
+// MinimumBags returns the minimum bag of each game, in order.
+func (gs Games) MinimumBags() []Bag {
+	bags := make([]Bag, len(gs))
+	for i, g := range gs {
+		bags[i] = g.MinimumBag()
+	}
+	return bags
+}
+
+// CheckColors returns an error for the first color shown in a game that
+// the bag does not hold at all, which is more likely a mistake in the
+// bag or the input than a game to rule out.
+func (gs Games) CheckColors(bag Bag) error {
+	for _, g := range gs {
+		for _, draw := range g.Draws {
+			for color := range draw {
+				if _, ok := bag[color]; !ok {
+					return fmt.Errorf("game %d: color %s is not in the bag", g.ID, color)
 				}
-				color := strings.TrimSpace(splits[1])
-				cubeMap[color] = max(cubeMap[color], num)
 			}
 		}
+	}
+	return nil
+}
+
+func (gs Games) SumIDs() int {
+	sum := 0
+	for _, g := range gs {
+		sum += g.ID
+	}
+	return sum
+}
+
+var bagFlag = flag.String("bag", "12 red, 13 green, 14 blue", "`contents` of the bag, or @file to read them from a file")
 
-		power := 1
-		for _, value := range cubeMap {
-			power *= value
+// loadBag returns the bag given with -bag, after checking that it holds
+// every color shown in games.
+func loadBag(games Games) Bag {
+	contents := *bagFlag
+	if filename, ok := strings.CutPrefix(contents, "@"); ok {
+		data, err := aoc.Load(filename)
+		if err != nil {
+			panic(err)
 		}
+		contents = data
+	}
+	bag, err := ParseBag(contents)
+	if err != nil {
+		panic(fmt.Errorf("-bag: %v", err))
+	}
+	if err := games.CheckColors(bag); err != nil {
+		panic(err)
+	}
+	return bag
+}
+
+func part1(lines []string) any {
+	games, err := ParseGames(lines)
+	if err != nil {
+		panic(err)
+	}
+	return games.FeasibleWith(loadBag(games)).SumIDs()
+}
 
-		powers += power
+// part2 multiplies the colors of the bag, so that a game with no cubes
+// of one of them has no power.
+func part2(lines []string) any {
+	games, err := ParseGames(lines)
+	if err != nil {
+		panic(err)
+	}
+	colors := loadBag(games).Colors()
+	powers := 0
+	for _, bag := range games.MinimumBags() {
+		powers += bag.Power(colors...)
 	}
+	return powers
+}
 
-	println(powers)
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.Literal("Game ")
+		s.Int()
+		s.Literal(":")
+		for {
+			s.Literal(" ")
+			s.Int()
+			s.Literal(" ")
+			s.Word()
+			if !s.Accept(",") && !s.Accept(";") {
+				break
+			}
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+	}
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      2,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
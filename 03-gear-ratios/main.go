@@ -1,127 +1,223 @@
 package main
 
 import (
-	"os"
-	"regexp"
+	"flag"
+	"fmt"
 	"strconv"
 	"strings"
+
+	"aoc"
 )
 
-func substring(str string, start int, end int) string {
-	start = max(start, 0)
-	end = min(end, len(str))
-	return str[start:end]
+type Coord struct {
+	x int
+	y int
 }
 
-func part1() {
-	content, err := os.ReadFile("input.txt")
-	if err != nil {
-		panic(err)
-	}
-	rows := strings.Split(string(content), "\n")
-	pattern := regexp.MustCompile("[0-9]+")
-	total := 0
-	for r, line := range rows {
-		for _, index := range pattern.FindAllStringIndex(line, -1) {
-			left, right := index[0]-1, index[1]
-			adjacent := substring(line, left, left+1) +
-				substring(line, right, right+1)
-			if r > 0 {
-				adjacent += substring(rows[r-1], left, right+1)
-			}
-			if r < len(rows)-1 {
-				adjacent += substring(rows[r+1], left, right+1)
-			}
-			adjacent = strings.ReplaceAll(adjacent, ".", "")
-			adjacent = pattern.ReplaceAllString(adjacent, "")
-			if len(adjacent) > 0 {
-				number, err := strconv.Atoi(line[left+1 : right])
-				if err != nil {
-					panic(err)
-				}
-				total += number
-			}
+// Number is a number in the schematic, spanning columns [Start, End) of
+// row Row. ID is its index in Schematic.Numbers, so two numbers with the
+// same value are still told apart.
+type Number struct {
+	ID         int
+	Value      int
+	Row        int
+	Start, End int
+}
+
+// Symbol is a character of the schematic that Rules.IsSymbol accepts.
+// ID is its index in Schematic.Symbols.
+type Symbol struct {
+	ID   int
+	Char byte
+	At   Coord
+}
+
+// Schematic is a parsed engine schematic, with adjacency between numbers
+// and symbols kept both ways round.
+type Schematic struct {
+	Numbers []Number
+	Symbols []Symbol
+	// SymbolsOf[n] are the ids of the symbols adjacent to number n, and
+	// NumbersOf[s] the ids of the numbers adjacent to symbol s.
+	SymbolsOf [][]int
+	NumbersOf [][]int
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// Rules are the variations of the puzzle that the same engine can solve.
+type Rules struct {
+	// Symbols are the characters that count as symbols. Empty means every
+	// character other than a digit or '.'.
+	Symbols string
+	// Gears are the symbols that can be gears, and GearParts the number
+	// of part numbers a gear must be adjacent to.
+	Gears     string
+	GearParts int
+	// Diagonals is whether diagonal neighbours are adjacent.
+	Diagonals bool
+	// Aggregate combines the part numbers of a gear into its ratio.
+	Aggregate func(values []int) int
+}
+
+var aggregates = map[string]func(values []int) int{
+	"product": func(values []int) int {
+		product := 1
+		for _, v := range values {
+			product *= v
+		}
+		return product
+	},
+	"sum": func(values []int) int {
+		sum := 0
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	},
+	"max": func(values []int) int {
+		most := 0
+		for _, v := range values {
+			most = max(most, v)
 		}
+		return most
+	},
+}
+
+// Puzzle are the rules of the original puzzle.
+var Puzzle = Rules{
+	Gears:     "*",
+	GearParts: 2,
+	Diagonals: true,
+	Aggregate: aggregates["product"],
+}
+
+func (r Rules) IsSymbol(c byte) bool {
+	if r.Symbols == "" {
+		return !isDigit(c) && c != '.'
 	}
-	println(total)
+	return strings.IndexByte(r.Symbols, c) != -1
 }
 
-type Coord struct {
-	x int
-	y int
+func (r Rules) IsGear(sym Symbol, parts []int) bool {
+	return strings.IndexByte(r.Gears, sym.Char) != -1 && len(parts) == r.GearParts
 }
 
-func part2() {
-	content, err := os.ReadFile("input.txt")
-	if err != nil {
-		panic(err)
+// adjacent reports whether cell is next to number n.
+func (r Rules) adjacent(n Number, cell Coord) bool {
+	if cell.y == n.Row {
+		return cell.x == n.Start-1 || cell.x == n.End
+	}
+	if cell.y != n.Row-1 && cell.y != n.Row+1 {
+		return false
 	}
-	rows := strings.Split(string(content), "\n")
-	pattern := regexp.MustCompile("[0-9]+")
-	coords := make(map[Coord]int)
+	if r.Diagonals {
+		return n.Start-1 <= cell.x && cell.x <= n.End
+	}
+	return n.Start <= cell.x && cell.x < n.End
+}
 
+func ParseSchematic(rows []string, rules Rules) *Schematic {
+	s := &Schematic{}
+	at := make(map[Coord]int)
 	for r, line := range rows {
-		for _, index := range pattern.FindAllStringIndex(line, -1) {
-			left, right := index[0]-1, index[1]
-			adjacent := substring(line, left, left+1) +
-				substring(line, right, right+1)
-			if r > 0 {
-				adjacent += substring(rows[r-1], left, right+1)
-			}
-			if r < len(rows)-1 {
-				adjacent += substring(rows[r+1], left, right+1)
-			}
-			adjacent = strings.ReplaceAll(adjacent, ".", "")
-			adjacent = pattern.ReplaceAllString(adjacent, "")
-			if len(adjacent) > 0 {
-				number, err := strconv.Atoi(line[left+1 : right])
+		for c := 0; c < len(line); c++ {
+			switch {
+			case isDigit(line[c]):
+				end := c
+				for end < len(line) && isDigit(line[end]) {
+					end++
+				}
+				value, err := strconv.Atoi(line[c:end])
 				if err != nil {
 					panic(err)
 				}
-				for x := left + 1; x < right; x++ {
-					coords[Coord{x, r}] = number
-				}
+				s.Numbers = append(s.Numbers, Number{len(s.Numbers), value, r, c, end})
+				c = end - 1
+			case rules.IsSymbol(line[c]):
+				at[Coord{c, r}] = len(s.Symbols)
+				s.Symbols = append(s.Symbols, Symbol{len(s.Symbols), line[c], Coord{c, r}})
 			}
 		}
 	}
 
-	ratios := 0
-	for r, line := range rows {
-		for c, char := range line {
-			if char != '*' {
-				continue
-			}
-			adjacent := []Coord{}
-			for y := r - 1; y <= r+1; y++ {
-				for x := c - 1; x <= c+1; x++ {
-					if x == c && y == r {
-						continue
-					}
-					if x < 0 || x >= len(line) || y < 0 || y >= len(rows) {
-						continue
-					}
-					adjacent = append(adjacent, Coord{x, y})
+	s.SymbolsOf = make([][]int, len(s.Numbers))
+	s.NumbersOf = make([][]int, len(s.Symbols))
+	for _, n := range s.Numbers {
+		for y := n.Row - 1; y <= n.Row+1; y++ {
+			for x := n.Start - 1; x <= n.End; x++ {
+				if id, ok := at[Coord{x, y}]; ok && rules.adjacent(n, Coord{x, y}) {
+					s.SymbolsOf[n.ID] = append(s.SymbolsOf[n.ID], id)
+					s.NumbersOf[id] = append(s.NumbersOf[id], n.ID)
 				}
 			}
-			parts := map[int]struct{}{}
-			for _, coord := range adjacent {
-				if coords[coord] != 0 {
-					parts[coords[coord]] = struct{}{}
-				}
-			}
-			keys := make([]int, 0, len(parts))
-			for k := range parts {
-				keys = append(keys, k)
-			}
-			if len(keys) == 2 {
-				ratios += keys[0] * keys[1]
-			}
 		}
 	}
-	println(ratios)
+	return s
+}
+
+// IsPart reports whether number n is a part number, which is to say it
+// is adjacent to a symbol.
+func (s *Schematic) IsPart(n Number) bool {
+	return len(s.SymbolsOf[n.ID]) > 0
+}
+
+var (
+	symbols   = flag.String("symbols", "", "characters that are symbols (default: all but digits and '.')")
+	gears     = flag.String("gears", Puzzle.Gears, "symbols that can be gears")
+	gearParts = flag.Int("gear-parts", Puzzle.GearParts, "part numbers a gear must be adjacent to")
+	diagonals = flag.Bool("diagonals", Puzzle.Diagonals, "count diagonal neighbours as adjacent")
+	aggregate = flag.String("aggregate", "product", "how a gear's part numbers make its ratio: product, sum or max")
+)
+
+func rules() Rules {
+	agg, ok := aggregates[*aggregate]
+	if !ok {
+		panic(fmt.Sprintf("unknown aggregate %q", *aggregate))
+	}
+	return Rules{*symbols, *gears, *gearParts, *diagonals, agg}
+}
+
+func part1(rows []string) any {
+	s := ParseSchematic(rows, rules())
+	total := 0
+	for _, n := range s.Numbers {
+		if s.IsPart(n) {
+			total += n.Value
+		}
+	}
+	return total
+}
+
+func part2(rows []string) any {
+	rules := rules()
+	s := ParseSchematic(rows, rules)
+	ratios := 0
+	for _, sym := range s.Symbols {
+		parts := s.NumbersOf[sym.ID]
+		if !rules.IsGear(sym, parts) {
+			continue
+		}
+		values := make([]int, len(parts))
+		for i, id := range parts {
+			values[i] = s.Numbers[id].Value
+		}
+		ratios += rules.Aggregate(values)
+	}
+	return ratios
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	return aoc.CheckGrid(lines, aoc.Digits+aoc.Punct)
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      3,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
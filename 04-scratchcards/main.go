@@ -1,90 +1,285 @@
 package main
 
 import (
-	"math"
-	"os"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+
+	"aoc"
 )
 
-func part1() {
-	content, err := os.ReadFile("input.txt")
+// Card is a scratchcard, with how many times each number is on it.
+type Card struct {
+	ID      int
+	Winning map[int]int
+	Have    map[int]int
+}
+
+func parseNumbers(s string) (map[int]int, error) {
+	counts := make(map[int]int)
+	for _, field := range strings.Fields(s) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		counts[n]++
+	}
+	return counts, nil
+}
+
+// ParseCard parses a line such as "Card 1: 41 48 83 | 83 86 6 31".
+func ParseCard(line string) (Card, error) {
+	prefix, numbers, ok := strings.Cut(line, ":")
+	if !ok {
+		return Card{}, fmt.Errorf("missing ':' in %q", line)
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(prefix, "Card")))
 	if err != nil {
-		panic(err)
+		return Card{}, err
+	}
+	winning, have, ok := strings.Cut(numbers, "|")
+	if !ok {
+		return Card{}, fmt.Errorf("card %d: missing '|'", id)
 	}
-	rows := strings.Split(string(content), "\n")
 
-	total := 0
-	for _, line := range rows {
-		line = strings.Split(line, ":")[1]
-		split := strings.Split(line, "|")
-		winningNums := strings.Split(strings.TrimSpace(split[0]), " ")
-		cardNums := strings.Split(strings.TrimSpace(split[1]), " ")
-
-		intersection := make([]string, 0)
-		for _, winningNum := range winningNums {
-			if winningNum == "" {
-				continue
+	card := Card{ID: id}
+	if card.Winning, err = parseNumbers(winning); err != nil {
+		return Card{}, fmt.Errorf("card %d: %v", id, err)
+	}
+	if card.Have, err = parseNumbers(have); err != nil {
+		return Card{}, fmt.Errorf("card %d: %v", id, err)
+	}
+	return card, nil
+}
+
+func ParseCards(rows []string) []Card {
+	cards := make([]Card, len(rows))
+	for i, line := range rows {
+		card, err := ParseCard(line)
+		if err != nil {
+			panic(err)
+		}
+		cards[i] = card
+	}
+	return cards
+}
+
+// Matches is the number of winning numbers the card has. A number that
+// is there more than once matches once for each pair of them.
+func (c Card) Matches() int {
+	matches := 0
+	for n, count := range c.Have {
+		matches += count * c.Winning[n]
+	}
+	return matches
+}
+
+// Scoring turns a card's matches into points.
+type Scoring func(matches int) int
+
+// scorings are the named scorings. Others are given by a formula, see
+// ParseScoring.
+var scorings = map[string]Scoring{
+	// the puzzle's: a point for the first match, doubled for every other
+	"double": func(matches int) int {
+		if matches == 0 {
+			return 0
+		}
+		return 1 << (matches - 1)
+	},
+	"linear": func(matches int) int {
+		return matches
+	},
+	"triangular": func(matches int) int {
+		return matches * (matches + 1) / 2
+	},
+}
+
+// ParseScoring returns the named scoring, or one of these formulas:
+//
+//	base:N         a point for the first match, times N for every other
+//	poly:C0,C1,... C0 + C1*m + C2*m^2 + ... points for m matches
+//
+// so double is base:2 and linear is poly:0,1.
+func ParseScoring(spec string) (Scoring, error) {
+	if scoring, ok := scorings[spec]; ok {
+		return scoring, nil
+	}
+	kind, arg, _ := strings.Cut(spec, ":")
+	if kind != "base" && kind != "poly" {
+		return nil, fmt.Errorf("unknown scoring %q, expected double, linear, triangular, base:N or poly:C0,C1,...", spec)
+	}
+	var nums []int
+	for _, field := range strings.Split(arg, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("scoring %q: %q is not a number", spec, field)
+		}
+		nums = append(nums, n)
+	}
+	if kind == "base" {
+		if len(nums) != 1 {
+			return nil, fmt.Errorf("scoring %q: base takes one number", spec)
+		}
+		return func(matches int) int {
+			if matches == 0 {
+				return 0
 			}
-			for _, cardNum := range cardNums {
-				if cardNum == "" {
-					continue
-				}
-				if winningNum == cardNum {
-					intersection = append(intersection, winningNum)
-				}
+			points := 1
+			for i := 1; i < matches; i++ {
+				points *= nums[0]
 			}
+			return points
+		}, nil
+	}
+	return func(matches int) int {
+		points := 0
+		for i := len(nums) - 1; i >= 0; i-- {
+			points = points*matches + nums[i]
 		}
-		matches := len(intersection)
-		if matches > 0 {
-			total += int(math.Pow(2, float64(matches-1)))
+		return points
+	}, nil
+}
+
+// Source is a card that won copies of another, and how many.
+type Source struct {
+	ID     int
+	Copies int
+}
+
+// Trace is the outcome of playing every copy of every card. Copies[i] is
+// how many instances of cards[i] there are in the end, the original
+// included, and From[i] the cards whose instances won the copies.
+type Trace struct {
+	Copies []int
+	From   [][]Source
+}
+
+// Propagate plays the cards in order, each instance of a card with m
+// matches winning a copy of each of the next m cards.
+func Propagate(cards []Card) Trace {
+	t := Trace{
+		Copies: make([]int, len(cards)),
+		From:   make([][]Source, len(cards)),
+	}
+	for i := range t.Copies {
+		t.Copies[i] = 1
+	}
+	for i, card := range cards {
+		end := min(i+1+card.Matches(), len(cards))
+		for j := i + 1; j < end; j++ {
+			t.Copies[j] += t.Copies[i]
+			t.From[j] = append(t.From[j], Source{card.ID, t.Copies[i]})
 		}
 	}
-	println(total)
+	return t
 }
 
-func part2() {
-	content, err := os.ReadFile("input.txt")
+func (t Trace) Total() int {
+	total := 0
+	for _, copies := range t.Copies {
+		total += copies
+	}
+	return total
+}
+
+var score = flag.String("score", "double", "how matches score in part 1: double, linear, triangular, base:N or poly:C0,C1,...")
+
+func scoring() Scoring {
+	scoring, err := ParseScoring(*score)
 	if err != nil {
 		panic(err)
 	}
-	rows := strings.Split(string(content), "\n")
+	return scoring
+}
 
-	copies := make(map[int]int, len(rows))
-	for base, line := range rows {
-		line = strings.Split(line, ":")[1]
-		split := strings.Split(line, "|")
-		winningNums := strings.Split(strings.TrimSpace(split[0]), " ")
-		cardNums := strings.Split(strings.TrimSpace(split[1]), " ")
+func part1(rows []string) any {
+	scoring := scoring()
+	total := 0
+	for _, card := range ParseCards(rows) {
+		total += scoring(card.Matches())
+	}
+	return total
+}
 
-		intersection := make([]string, 0)
-		for _, winningNum := range winningNums {
-			if winningNum == "" {
-				continue
-			}
-			for _, cardNum := range cardNums {
-				if cardNum == "" {
-					continue
-				}
-				if winningNum == cardNum {
-					intersection = append(intersection, winningNum)
-				}
-			}
+func part2(rows []string) any {
+	return Propagate(ParseCards(rows)).Total()
+}
+
+// report lists each card's matches and points, and for part 2 how many
+// copies of it were won and by which cards.
+func report(w io.Writer, part int, rows []string) error {
+	cards := ParseCards(rows)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if part == 1 {
+		scoring := scoring()
+		fmt.Fprintln(tw, "CARD\tMATCHES\tPOINTS")
+		total := 0
+		for _, card := range cards {
+			points := scoring(card.Matches())
+			fmt.Fprintf(tw, "%d\t%d\t%d\n", card.ID, card.Matches(), points)
+			total += points
 		}
-		matches := len(intersection)
-		baseCopy := copies[base+1]
-		for i := 0; i < matches; i++ {
-			copies[base+1+i+1] += baseCopy + 1
+		fmt.Fprintf(tw, "TOTAL\t\t%d\n", total)
+		return tw.Flush()
+	}
+
+	trace := Propagate(cards)
+	fmt.Fprintln(tw, "CARD\tMATCHES\tCOPIES\tWON FROM CARD:COPIES")
+	for i, card := range cards {
+		from := make([]string, len(trace.From[i]))
+		for j, src := range trace.From[i] {
+			from[j] = fmt.Sprintf("%d:%d", src.ID, src.Copies)
 		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", card.ID, card.Matches(), trace.Copies[i], strings.Join(from, " "))
 	}
+	fmt.Fprintf(tw, "TOTAL\t\t%d\n", trace.Total())
+	return tw.Flush()
+}
 
-	total := len(rows)
-	for _, copies := range copies {
-		total += copies
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.Literal("Card")
+		s.Spaces()
+		s.Int()
+		s.Literal(":")
+
+		winning := make(map[int]bool)
+		for s.Spaces() && !s.Accept("|") {
+			col := s.Col()
+			n, ok := s.Int()
+			if ok && winning[n] {
+				diags = append(diags, aoc.NewDiagnostic(i+1, col, "duplicate winning number %d", n))
+			}
+			winning[n] = true
+		}
+		have := make(map[int]bool)
+		for s.More() {
+			s.Spaces()
+			col := s.Col()
+			n, ok := s.Int()
+			if ok && have[n] {
+				diags = append(diags, aoc.NewDiagnostic(i+1, col, "duplicate number %d, which matches once for each copy", n))
+			}
+			have[n] = true
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
 	}
-	println(total)
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      4,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+		Report:   report,
+	})
 }
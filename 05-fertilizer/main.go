@@ -1,12 +1,17 @@
 package main
 
 import (
-	"os"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"unicode"
+	"text/tabwriter"
+
+	"aoc"
 )
 
 type MapRange struct {
@@ -18,9 +23,11 @@ type MapRange struct {
 }
 
 func MapRangeFromNums(nums []int) MapRange {
-	start := nums[1]
-	end := nums[1] + nums[2]
-	diff := nums[0] - nums[1]
+	return NewMapRange(nums[1], nums[1]+nums[2], nums[0]-nums[1])
+}
+
+// NewMapRange returns the range that moves [start, end) by diff.
+func NewMapRange(start, end, diff int) MapRange {
 	return MapRange{
 		start:     start,
 		end:       end,
@@ -30,33 +37,200 @@ func MapRangeFromNums(nums []int) MapRange {
 	}
 }
 
-type Map []MapRange
+// Map translates the numbers in each of its ranges by the range's diff,
+// and leaves every other number alone. Its ranges are held sorted both
+// by start and by destination, so lookups either way are binary searches.
+type Map struct {
+	ranges []MapRange
+	byDest []MapRange
+	// backErr is why the map cannot be followed backwards, nil if it is
+	// one to one. It is worked out once, when the map is built.
+	backErr error
+}
 
-func MapFromNumss(numss [][]int) Map {
-	m := Map{}
-	for _, nums := range numss {
-		m = append(m, MapRangeFromNums(nums))
+// NewMap returns the map made of ranges. Ranges that overlap are an
+// error: the map would not say where their numbers go. Ranges that move
+// numbers onto the same destination are not, since the puzzle only
+// follows maps forwards, where they are still well defined; such a map
+// fails when it is followed backwards, by BackTranslate or Inverse.
+func NewMap(ranges []MapRange) (Map, error) {
+	m := newMap(ranges)
+	for i := 1; i < len(m.ranges); i++ {
+		if prev, mr := m.ranges[i-1], m.ranges[i]; mr.start < prev.end {
+			return Map{}, fmt.Errorf("ranges %d-%d and %d-%d overlap", prev.start, prev.end-1, mr.start, mr.end-1)
+		}
 	}
-	Sort(m)
+	return m, nil
+}
+
+// newMap builds the indexes of a map whose ranges are known not to
+// overlap, though their destinations may.
+func newMap(ranges []MapRange) Map {
+	m := Map{ranges: slices.Clone(ranges), byDest: slices.Clone(ranges)}
+	Sort(m.ranges)
+	sort.Slice(m.byDest, func(i, j int) bool {
+		return m.byDest[i].destStart < m.byDest[j].destStart
+	})
+	m.backErr = m.oneToOne()
 	return m
 }
 
-func (m Map) Translate(num int) int {
-	for _, mr := range m {
-		if num >= mr.start && num < mr.end {
-			return num + mr.diff
+// oneToOne returns nil if m moves numbers exactly onto the numbers it
+// moves away, in some order, and why not otherwise.
+func (m Map) oneToOne() error {
+	var from, to []Range
+	for i, mr := range m.byDest {
+		if i > 0 && mr.destStart < m.byDest[i-1].destEnd {
+			return fmt.Errorf("%d is reached from more than one number", mr.destStart)
 		}
+		from = appendRange(from, Range{m.ranges[i].start, m.ranges[i].end})
+		to = appendRange(to, Range{mr.destStart, mr.destEnd})
+	}
+	if !slices.Equal(from, to) {
+		return errors.New("map moves numbers onto numbers it leaves alone")
+	}
+	return nil
+}
+
+func MapFromNumss(numss [][]int) (Map, error) {
+	ranges := make([]MapRange, len(numss))
+	for i, nums := range numss {
+		ranges[i] = MapRangeFromNums(nums)
+	}
+	return NewMap(ranges)
+}
+
+// Ranges returns the ranges of m, sorted by start.
+func (m Map) Ranges() []MapRange {
+	return m.ranges
+}
+
+func (m Map) Translate(num int) int {
+	// the last range starting at or before num is the only one that can
+	// hold it
+	i := sort.Search(len(m.ranges), func(i int) bool { return m.ranges[i].start > num }) - 1
+	if i >= 0 && num < m.ranges[i].end {
+		return num + m.ranges[i].diff
 	}
 	return num
 }
 
-func (m Map) BackTranslate(num int) int {
-	for _, mr := range m {
-		if num >= mr.destStart && num < mr.destEnd {
-			return num - mr.diff
+// BackTranslate returns the number that m translates to num, or an error
+// if m is not one to one.
+func (m Map) BackTranslate(num int) (int, error) {
+	if m.backErr != nil {
+		return 0, m.backErr
+	}
+	i := sort.Search(len(m.byDest), func(i int) bool { return m.byDest[i].destStart > num }) - 1
+	if i >= 0 && num < m.byDest[i].destEnd {
+		return num - m.byDest[i].diff, nil
+	}
+	return num, nil
+}
+
+// split cuts r into pieces at the boundaries of the ranges of m, each
+// with the diff it is translated by. Pieces that m leaves alone have a
+// diff of 0.
+func (m Map) split(r Range) []MapRange {
+	var out []MapRange
+	first := sort.Search(len(m.ranges), func(i int) bool { return m.ranges[i].end > r.start })
+	for _, mr := range m.ranges[first:] {
+		if r.start >= r.end || mr.start >= r.end {
+			break
 		}
+		if r.start < mr.start {
+			out = append(out, NewMapRange(r.start, mr.start, 0))
+			r.start = mr.start
+		}
+		end := min(r.end, mr.end)
+		out = append(out, NewMapRange(r.start, end, mr.diff))
+		r.start = end
 	}
-	return num
+	if r.start < r.end {
+		out = append(out, NewMapRange(r.start, r.end, 0))
+	}
+	return out
+}
+
+// TranslateRange translates every number in r, splitting it wherever it
+// crosses the boundary of a MapRange. The pieces are in order of the
+// numbers they came from, not of where they end up.
+func (m Map) TranslateRange(r Range) []Range {
+	pieces := m.split(r)
+	out := make([]Range, len(pieces))
+	for i, p := range pieces {
+		out[i] = Range{p.destStart, p.destEnd}
+	}
+	return out
+}
+
+// Normalize returns m without ranges that move nothing and with touching
+// ranges that move by the same diff merged, so that two maps of the same
+// function are equal.
+func (m Map) Normalize() Map {
+	return normalize(m.ranges)
+}
+
+func normalize(ranges []MapRange) Map {
+	sorted := slices.Clone(ranges)
+	Sort(sorted)
+	var out []MapRange
+	for _, mr := range sorted {
+		if mr.diff == 0 || mr.start == mr.end {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].end == mr.start && out[n-1].diff == mr.diff {
+			out[n-1] = NewMapRange(out[n-1].start, mr.end, mr.diff)
+			continue
+		}
+		out = append(out, mr)
+	}
+	return newMap(out)
+}
+
+// Then returns the map that translates by m and then by next.
+func (m Map) Then(next Map) Map {
+	m, next = m.Normalize(), next.Normalize()
+	var out []MapRange
+	// numbers that m moves, wherever next then takes them
+	for _, mr := range m.ranges {
+		for _, p := range next.split(Range{mr.destStart, mr.destEnd}) {
+			out = append(out, NewMapRange(p.start-mr.diff, p.end-mr.diff, mr.diff+p.diff))
+		}
+	}
+	// numbers that only next moves
+	for _, nr := range next.ranges {
+		for _, p := range m.split(Range{nr.start, nr.end}) {
+			if p.diff == 0 {
+				out = append(out, NewMapRange(p.start, p.end, nr.diff))
+			}
+		}
+	}
+	return normalize(out)
+}
+
+// Inverse returns the map that undoes m. Only a map that is one to one
+// has an inverse: one that moves numbers exactly onto the numbers it
+// moves away, in some order.
+func (m Map) Inverse() (Map, error) {
+	if m.backErr != nil {
+		return Map{}, m.backErr
+	}
+	inverse := make([]MapRange, len(m.byDest))
+	for i, mr := range m.byDest {
+		inverse[i] = NewMapRange(mr.destStart, mr.destEnd, -mr.diff)
+	}
+	return normalize(inverse), nil
+}
+
+// appendRange appends r to sorted, disjoint ranges, merging it with the
+// last one if they touch.
+func appendRange(ranges []Range, r Range) []Range {
+	if n := len(ranges); n > 0 && ranges[n-1].end == r.start {
+		ranges[n-1].end = r.end
+		return ranges
+	}
+	return append(ranges, r)
 }
 
 func Sort[Map ~[]MapRange](m Map) {
@@ -74,11 +248,34 @@ func (m Maps) Translate(num int) int {
 	return num
 }
 
-func (m Maps) BackTranslate(num int) int {
+func (m Maps) BackTranslate(num int) (int, error) {
 	for i := len(m) - 1; i >= 0; i-- {
-		num = m[i].BackTranslate(num)
+		var err error
+		if num, err = m[i].BackTranslate(num); err != nil {
+			return 0, err
+		}
 	}
-	return num
+	return num, nil
+}
+
+func (m Maps) TranslateRanges(ranges []Range) []Range {
+	for _, m := range m {
+		var next []Range
+		for _, r := range ranges {
+			next = append(next, m.TranslateRange(r)...)
+		}
+		ranges = next
+	}
+	return ranges
+}
+
+// Compose returns the single map that translates by every map in turn.
+func (m Maps) Compose() Map {
+	var composed Map
+	for _, m := range m {
+		composed = composed.Then(m)
+	}
+	return composed
 }
 
 func ParseNums(line string) []int {
@@ -96,92 +293,322 @@ func ParseNums(line string) []int {
 	return nums
 }
 
-type SeedRange struct {
+// Range is the numbers from start up to but not including end.
+type Range struct {
 	start int
 	end   int
 }
 
-func part1() {
-	content, err := os.ReadFile("input.txt")
-	if err != nil {
-		panic(err)
+// CategoryMap is a map from numbers of one category to another, as in
+// "seed-to-soil map:".
+type CategoryMap struct {
+	From, To string
+	Map      Map
+}
+
+// Almanac is the seeds and the maps between categories, which form a
+// graph that can be followed either way.
+type Almanac struct {
+	Seeds []int
+	Maps  []CategoryMap
+}
+
+// LineError is an error found at a line of the almanac.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ParseAlmanac reads the seeds and the maps, whatever order the maps
+// come in. Two maps between the same categories, or maps that lead round
+// in a cycle, are an error.
+func ParseAlmanac(rows []string) (*Almanac, error) {
+	blocks, starts := aoc.Blocks(rows)
+	if len(blocks) == 0 {
+		return nil, errors.New("empty almanac")
+	}
+	seeds, ok := strings.CutPrefix(blocks[0][0], "seeds:")
+	if !ok {
+		return nil, errors.New("line 1: expected seeds")
+	}
+	a := &Almanac{Seeds: ParseNums(seeds)}
+
+	for i, block := range blocks[1:] {
+		name, ok := strings.CutSuffix(block[0], " map:")
+		from, to, ok2 := strings.Cut(name, "-to-")
+		if !ok || !ok2 {
+			return nil, &LineError{starts[i+1] + 1, fmt.Errorf("expected a map header, found %q", block[0])}
+		}
+		for _, cm := range a.Maps {
+			if cm.From == from && cm.To == to {
+				return nil, &LineError{starts[i+1] + 1, fmt.Errorf("second map from %s to %s", from, to)}
+			}
+		}
+		numss := make([][]int, len(block)-1)
+		for j, row := range block[1:] {
+			numss[j] = ParseNums(row)
+		}
+		m, err := MapFromNumss(numss)
+		if err != nil {
+			return nil, &LineError{starts[i+1] + 1, fmt.Errorf("%s map: %v", name, err)}
+		}
+		a.Maps = append(a.Maps, CategoryMap{from, to, m})
 	}
-	rows := strings.Split(string(content), "\n")
 
-	// parse seeds
-	seeds := ParseNums(strings.Split(rows[0], ":")[1])
+	if cycle := a.cycle(); cycle != nil {
+		return nil, fmt.Errorf("maps form a cycle: %s", strings.Join(cycle, " to "))
+	}
+	return a, nil
+}
 
-	// build maps
-	maps := Maps{}
-	numss := [][]int{}
-	for _, row := range rows[1:] {
-		if row == "" || !unicode.IsDigit(rune(row[0])) {
-			if len(numss) > 0 {
-				maps = append(maps, MapFromNumss(numss))
+// cycle returns the categories of a cycle of maps, if there is one.
+func (a *Almanac) cycle() []string {
+	const (
+		unseen = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(category string) []string
+	visit = func(category string) []string {
+		switch state[category] {
+		case visiting:
+			start := slices.Index(path, category)
+			return append(slices.Clone(path[start:]), category)
+		case done:
+			return nil
+		}
+		state[category] = visiting
+		path = append(path, category)
+		for _, cm := range a.Maps {
+			if cm.From == category {
+				if cycle := visit(cm.To); cycle != nil {
+					return cycle
+				}
 			}
-			numss = [][]int{}
-			continue
 		}
-		nums := ParseNums(row)
-		if len(nums) > 0 {
-			numss = append(numss, nums)
+		path = path[:len(path)-1]
+		state[category] = done
+		return nil
+	}
+	for _, cm := range a.Maps {
+		if cycle := visit(cm.From); cycle != nil {
+			return cycle
 		}
 	}
-	maps = append(maps, MapFromNumss(numss))
+	return nil
+}
 
-	// translate seeds
-	locations := make([]int, len(seeds))
-	for i, seed := range seeds {
-		locations[i] = maps.Translate(seed)
+// Converter returns the map from numbers of category from to numbers of
+// category to, following the fewest maps there are between them. Maps
+// are followed backwards through their inverse where needed.
+func (a *Almanac) Converter(from, to string) (Map, error) {
+	type step struct {
+		category string
+		via      int // index into a.Maps, -1 for the start
+		inverse  bool
+		prev     int
+	}
+	steps := []step{{from, -1, false, -1}}
+	seen := map[string]bool{from: true}
+	for i := 0; i < len(steps) && !seen[to]; i++ {
+		for j, cm := range a.Maps {
+			next, inverse := cm.To, false
+			if cm.To == steps[i].category {
+				next, inverse = cm.From, true
+			} else if cm.From != steps[i].category {
+				continue
+			}
+			if !seen[next] {
+				seen[next] = true
+				steps = append(steps, step{next, j, inverse, i})
+			}
+		}
+	}
+	end := slices.IndexFunc(steps, func(s step) bool { return s.category == to })
+	if end == -1 {
+		return Map{}, fmt.Errorf("no chain of maps from %s to %s", from, to)
 	}
 
-	println(slices.Min(locations))
+	var maps Maps
+	for i := end; steps[i].via != -1; i = steps[i].prev {
+		cm := a.Maps[steps[i].via]
+		m := cm.Map
+		if steps[i].inverse {
+			var err error
+			if m, err = m.Inverse(); err != nil {
+				return Map{}, fmt.Errorf("cannot map %s back to %s: %v", cm.To, cm.From, err)
+			}
+		}
+		maps = append(Maps{m}, maps...)
+	}
+	return maps.Compose(), nil
 }
 
-func part2() {
-	content, err := os.ReadFile("input.txt")
+var (
+	from = flag.String("from", "seed", "`category` of the seed numbers")
+	to   = flag.String("to", "location", "`category` to find the lowest number of")
+)
+
+// converter parses the almanac and returns the seeds and the map for
+// -from and -to.
+func converter(rows []string) ([]int, Map) {
+	a, err := ParseAlmanac(rows)
+	if err != nil {
+		panic(err)
+	}
+	m, err := a.Converter(*from, *to)
 	if err != nil {
 		panic(err)
 	}
-	rows := strings.Split(string(content), "\n")
+	return a.Seeds, m
+}
+
+func part1(rows []string) any {
+	seeds, almanac := converter(rows)
+
+	// translate seeds
+	locations := make([]int, len(seeds))
+	for i, seed := range seeds {
+		locations[i] = almanac.Translate(seed)
+	}
+
+	return slices.Min(locations)
+}
 
-	// parse seeds
-	seeds := ParseNums(strings.Split(rows[0], ":")[1])
-	ranges := []SeedRange{}
+func part2(rows []string) any {
+	seeds, almanac := converter(rows)
+	ranges := []Range{}
 	for i := 0; i < len(seeds); i += 2 {
-		ranges = append(ranges, SeedRange{seeds[i], seeds[i] + seeds[i+1]})
+		ranges = append(ranges, Range{seeds[i], seeds[i] + seeds[i+1]})
 	}
 
-	// build maps
-	maps := Maps{}
-	numss := [][]int{}
-	for _, row := range rows[1:] {
-		if row == "" || !unicode.IsDigit(rune(row[0])) {
-			if len(numss) > 0 {
-				maps = append(maps, MapFromNumss(numss))
-			}
-			numss = [][]int{}
-			continue
+	location := -1
+	var locations []Range
+	for _, r := range ranges {
+		locations = append(locations, almanac.TranslateRange(r)...)
+	}
+	for _, r := range locations {
+		if location == -1 || r.start < location {
+			location = r.start
 		}
-		nums := ParseNums(row)
-		if len(nums) > 0 {
-			numss = append(numss, nums)
+	}
+	if location == -1 {
+		panic("seed ranges are all empty")
+	}
+	return location
+}
+
+// report shows each seed's location for part 1, and for part 2 exports
+// the whole seed-to-location function (or -from to -to): the ranges of
+// seeds it moves and where to, with every other seed staying where it is.
+func report(w io.Writer, part int, rows []string) error {
+	seeds, almanac := converter(rows)
+	src, dst := strings.ToUpper(*from), strings.ToUpper(*to)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if part == 1 {
+		fmt.Fprintf(tw, "%s\t%s\t\n", src, dst)
+		for _, seed := range seeds {
+			fmt.Fprintf(tw, "%d\t%d\t\n", seed, almanac.Translate(seed))
 		}
+		return tw.Flush()
 	}
-	maps = append(maps, MapFromNumss(numss))
 
-	for location := 0; ; location++ {
-		seed := maps.BackTranslate(location)
-		for _, seedRange := range ranges {
-			if seed >= seedRange.start && seed < seedRange.end {
-				println(location)
-				return
-			}
+	fmt.Fprintf(tw, "%sS FROM\tTO\t%sS FROM\tTO\tDIFF\t\n", src, dst)
+	for _, mr := range almanac.Ranges() {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%+d\t\n", mr.start, mr.end-1, mr.destStart, mr.destEnd-1, mr.diff)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if _, err := almanac.Inverse(); err != nil {
+		return fmt.Errorf("%s-to-%s is not one to one: %v", *from, *to, err)
+	}
+	return nil
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	blocks, starts := aoc.Blocks(lines)
+	if len(blocks) == 0 {
+		return []aoc.Diagnostic{aoc.NewDiagnostic(1, 1, "empty almanac")}
+	}
+
+	var diags []aoc.Diagnostic
+	s := aoc.NewScanner(starts[0]+1, blocks[0][0])
+	s.Literal("seeds:")
+	seeds := 0
+	for s.More() {
+		s.Spaces()
+		s.Int()
+		seeds++
+	}
+	s.End()
+	diags = append(diags, s.Diagnostics()...)
+	if s.Ok() && (seeds == 0 || seeds%2 == 1) {
+		diags = append(diags, aoc.NewDiagnostic(
+			starts[0]+1, s.Col(),
+			"found %d seeds, expected a non-zero number of start/length pairs", seeds,
+		))
+	}
+	for i := range blocks[0][1:] {
+		diags = append(diags, aoc.NewDiagnostic(starts[0]+i+2, 1, "expected a blank line after seeds"))
+	}
+
+	headers := map[string]int{}
+	for b, block := range blocks[1:] {
+		start := starts[b+1]
+		s := aoc.NewScanner(start+1, block[0])
+		src, _ := s.Word()
+		s.Literal("-to-")
+		dst, _ := s.Word()
+		s.Literal(" map:")
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if line, ok := headers[block[0]]; ok && s.Ok() {
+			diags = append(diags, aoc.NewDiagnostic(
+				start+1, 1, "second map from %q to %q, the first is on line %d", src, dst, line,
+			))
+		}
+		headers[block[0]] = start + 1
+
+		for i, row := range block[1:] {
+			s := aoc.NewScanner(start+i+2, row)
+			s.Int()
+			s.Spaces()
+			s.Int()
+			s.Spaces()
+			s.Int()
+			s.End()
+			diags = append(diags, s.Diagnostics()...)
 		}
 	}
+	if len(diags) > 0 {
+		return diags
+	}
+
+	// the maps may come in any order, as long as they lead somewhere
+	var lineErr *LineError
+	if a, err := ParseAlmanac(lines); errors.As(err, &lineErr) {
+		diags = append(diags, aoc.NewDiagnostic(lineErr.Line, 1, "%v", lineErr.Err))
+	} else if err != nil {
+		diags = append(diags, aoc.NewDiagnostic(1, 1, "%v", err))
+	} else if _, err := a.Converter(*from, *to); err != nil {
+		diags = append(diags, aoc.NewDiagnostic(1, 1, "%v", err))
+	}
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      5,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+		Report:   report,
+	})
 }
@@ -1,77 +1,336 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"math"
-	"os"
+	"math/big"
 	"strconv"
 	"strings"
+
+	"aoc"
 )
 
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
+// Given a race of time T and a record R, holding the button for n
+// milliseconds beats the record when
+//
+//	(T - n) * n > R
+//
+// which holds strictly between the roots n = (T ± sqrt(T^2 - 4R)) / 2.
+// Both the square root and the rounding are done in integers, so a tie
+// with the record is never counted however large the numbers get.
+
+// maxSquarable is the largest T whose square fits in an int64.
+const maxSquarable = 3037000499
+
+// Wins returns the range of hold times lo..hi that beat record in a race
+// of time t, or ok false if there are none. Races small enough are solved
+// in int64, and the rest with big.Int.
+func Wins(t, record *big.Int) (lo, hi *big.Int, ok bool) {
+	if t.IsInt64() && record.IsInt64() {
+		t, r := t.Int64(), record.Int64()
+		// 4r must fit too, which it does when r <= t*t/4; larger records
+		// cannot be beaten and are left to big.Int like any other
+		if 0 <= t && t <= maxSquarable && 0 <= r && r <= t*t/4 {
+			lo, hi, ok := winsInt(t, r)
+			return big.NewInt(lo), big.NewInt(hi), ok
+		}
+	}
+	return winsBig(t, record)
+}
+
+func winsInt(t, r int64) (lo, hi int64, ok bool) {
+	discriminant := t*t - 4*r
+	if discriminant <= 0 {
+		return 0, 0, false
+	}
+	s := isqrt(discriminant)
+	// (t - s) / 2 is at most one above the last hold time that loses
+	lo = (t - s) / 2
+	if (t-lo)*lo <= r {
+		lo++
+	}
+	lo = max(lo, 0)
+	hi = t - lo
+	return lo, hi, lo <= hi
+}
+
+// isqrt is the integer square root of n >= 0, corrected from the float64
+// estimate, which can be off by one either way for large n.
+func isqrt(n int64) int64 {
+	s := int64(math.Sqrt(float64(n)))
+	for s > 0 && s*s > n {
+		s--
+	}
+	for s < maxSquarable && (s+1)*(s+1) <= n {
+		s++
+	}
+	return s
+}
+
+func winsBig(t, record *big.Int) (lo, hi *big.Int, ok bool) {
+	discriminant := new(big.Int).Mul(t, t)
+	discriminant.Sub(discriminant, new(big.Int).Lsh(record, 2))
+	if discriminant.Sign() <= 0 {
+		return nil, nil, false
+	}
+	s := new(big.Int).Sqrt(discriminant)
+	lo = new(big.Int).Sub(t, s)
+	lo.Rsh(lo, 1)
+	if distance := new(big.Int).Mul(new(big.Int).Sub(t, lo), lo); distance.Cmp(record) <= 0 {
+		lo.Add(lo, big.NewInt(1))
+	}
+	if lo.Sign() < 0 {
+		lo.SetInt64(0)
+	}
+	hi = new(big.Int).Sub(t, lo)
+	return lo, hi, lo.Cmp(hi) <= 0
+}
+
+// Model is a law for how far a boat goes in a race of time t when the
+// button is held for n milliseconds. The distance must not fall as n
+// rises to Best(t), nor rise after it.
+type Model interface {
+	Distance(t, n *big.Int) *big.Int
+	Best(t *big.Int) *big.Int
+}
+
+// Solver is implemented by models that can find their winning hold
+// times directly, without searching.
+type Solver interface {
+	Wins(t, record *big.Int) (lo, hi *big.Int, ok bool)
+}
+
+var one = big.NewInt(1)
+
+// Linear is the puzzle's model: every millisecond held adds a millimetre
+// per millisecond of speed.
+type Linear struct{}
+
+func (Linear) Distance(t, n *big.Int) *big.Int {
+	return new(big.Int).Mul(n, new(big.Int).Sub(t, n))
+}
+
+func (Linear) Best(t *big.Int) *big.Int {
+	return new(big.Int).Rsh(t, 1)
+}
+
+func (Linear) Wins(t, record *big.Int) (lo, hi *big.Int, ok bool) {
+	return Wins(t, record)
+}
+
+// ChargeCost is Linear, but the first Cost milliseconds held only
+// charge the boat and add no speed.
+type ChargeCost struct {
+	Cost int64
+}
+
+func (m ChargeCost) Distance(t, n *big.Int) *big.Int {
+	speed := new(big.Int).Sub(n, big.NewInt(m.Cost))
+	if speed.Sign() < 0 {
+		return new(big.Int)
+	}
+	return speed.Mul(speed, new(big.Int).Sub(t, n))
+}
+
+func (m ChargeCost) Best(t *big.Int) *big.Int {
+	best := new(big.Int).Add(t, big.NewInt(m.Cost))
+	best.Rsh(best, 1)
+	if best.Cmp(t) > 0 {
+		// all the time is spent charging
+		best.Set(t)
+	}
+	return best
+}
+
+// Power accelerates the boat: holding for n gives a speed of n^Exp.
+type Power struct {
+	Exp int64
+}
+
+func (m Power) Distance(t, n *big.Int) *big.Int {
+	speed := new(big.Int).Exp(n, big.NewInt(m.Exp), nil)
+	return speed.Mul(speed, new(big.Int).Sub(t, n))
+}
+
+// Best is next to Exp*t/(Exp+1), where the real curve peaks.
+func (m Power) Best(t *big.Int) *big.Int {
+	best := new(big.Int).Mul(t, big.NewInt(m.Exp))
+	best.Quo(best, big.NewInt(m.Exp+1))
+	next := new(big.Int).Add(best, one)
+	if next.Cmp(t) <= 0 && m.Distance(t, next).Cmp(m.Distance(t, best)) > 0 {
+		return next
+	}
+	return best
+}
+
+// Capped is Linear with the speed limited to Cap.
+type Capped struct {
+	Cap int64
+}
+
+func (m Capped) Distance(t, n *big.Int) *big.Int {
+	speed := new(big.Int).Set(n)
+	if speed.Cmp(big.NewInt(m.Cap)) > 0 {
+		speed.SetInt64(m.Cap)
 	}
+	return speed.Mul(speed, new(big.Int).Sub(t, n))
+}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
+func (m Capped) Best(t *big.Int) *big.Int {
+	best := new(big.Int).Rsh(t, 1)
+	if best.Cmp(big.NewInt(m.Cap)) > 0 {
+		best.SetInt64(m.Cap)
+	}
+	return best
 }
 
-func ParseNums(line string) []int {
-	nums := []int{}
-	for _, num := range strings.Split(line, " ") {
-		if num == "" {
-			continue
+// ParseModel parses "linear", "cost:N", "power:N" or "cap:N".
+func ParseModel(s string) (Model, error) {
+	name, arg, _ := strings.Cut(s, ":")
+	switch name {
+	case "linear":
+		if arg != "" {
+			return nil, fmt.Errorf("model %q: linear takes no argument", s)
 		}
-		n, err := strconv.Atoi(strings.TrimSpace(num))
-		if err != nil {
-			panic(err)
+		return Linear{}, nil
+	case "cost", "power", "cap":
+	default:
+		return nil, fmt.Errorf("unknown model %q", s)
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("model %q: expected a non-negative number after %s:", s, name)
+	}
+	switch name {
+	case "cost":
+		return ChargeCost{n}, nil
+	case "power":
+		if n == 0 {
+			return nil, fmt.Errorf("model %q: power must be at least 1", s)
 		}
-		nums = append(nums, n)
+		return Power{n}, nil
+	default:
+		return Capped{n}, nil
 	}
-	return nums
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
-	times := ParseNums(strings.Split(lines[0], ":")[1])
-	distances := ParseNums(strings.Split(lines[1], ":")[1])
+// search returns the first n in lo..hi for which f is true, or hi+1 if
+// there is none. f must be false up to some n and true from there on.
+func search(lo, hi *big.Int, f func(n *big.Int) bool) *big.Int {
+	lo, hi = new(big.Int).Set(lo), new(big.Int).Add(hi, one)
+	for lo.Cmp(hi) < 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if f(mid) {
+			hi = mid
+		} else {
+			lo = mid.Add(mid, one)
+		}
+	}
+	return lo
+}
 
-	// Given time is T, distance is D, record is R
-	// and we hold the button for n milliseconds, we have the constraint:
-	// D = (T - n) * n > R
-	// So the root for n is
-	// n = (T ± sqrt(T^2 - 4R)) / 2
-	ways := 1
-	for i, t := range times {
-		d := distances[i]
-		discriminant := float64(t*t - 4*d)
-		small := math.Ceil((float64(t) - math.Sqrt(discriminant)) / 2)
-		large := math.Floor((float64(t) + math.Sqrt(discriminant)) / 2)
-		ways *= int(large) - int(small) + 1
+// Bisect finds the winning hold times of any model, by searching either
+// side of its best hold time, where the distance is monotone.
+func Bisect(m Model, t, record *big.Int) (lo, hi *big.Int, ok bool) {
+	best := m.Best(t)
+	if m.Distance(t, best).Cmp(record) <= 0 {
+		return nil, nil, false
+	}
+	beats := func(n *big.Int) bool { return m.Distance(t, n).Cmp(record) > 0 }
+	lo = search(new(big.Int), best, beats)
+	hi = search(best, t, func(n *big.Int) bool { return !beats(n) })
+	return lo, hi.Sub(hi, one), true
+}
+
+// Ways is the number of hold times that beat record under model m.
+func Ways(m Model, t, record *big.Int) *big.Int {
+	var lo, hi *big.Int
+	var ok bool
+	if solver, isSolver := m.(Solver); isSolver {
+		lo, hi, ok = solver.Wins(t, record)
+	} else {
+		lo, hi, ok = Bisect(m, t, record)
+	}
+	if !ok {
+		return new(big.Int)
 	}
-	println(ways)
+	ways := new(big.Int).Sub(hi, lo)
+	return ways.Add(ways, one)
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+var modelFlag = flag.String("model", "linear", "boat `model`: linear, cost:N, power:N or cap:N")
 
-	t, err := strconv.Atoi(strings.ReplaceAll(strings.Split(lines[0], ":")[1], " ", ""))
+func model() Model {
+	m, err := ParseModel(*modelFlag)
 	if err != nil {
 		panic(err)
 	}
-	d, err := strconv.Atoi(strings.ReplaceAll(strings.Split(lines[1], ":")[1], " ", ""))
-	if err != nil {
-		panic(err)
+	return m
+}
+
+func parseBig(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("invalid number %q", s))
+	}
+	return n
+}
+
+func part1(lines []string) any {
+	times := strings.Fields(strings.Split(lines[0], ":")[1])
+	records := strings.Fields(strings.Split(lines[1], ":")[1])
+
+	m := model()
+	ways := big.NewInt(1)
+	for i, t := range times {
+		ways.Mul(ways, Ways(m, parseBig(t), parseBig(records[i])))
+	}
+	return ways
+}
+
+// part2 reads each line as one number, with the spaces taken out.
+func part2(lines []string) any {
+	t := parseBig(strings.ReplaceAll(strings.Split(lines[0], ":")[1], " ", ""))
+	record := parseBig(strings.ReplaceAll(strings.Split(lines[1], ":")[1], " ", ""))
+	return Ways(model(), t, record)
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	if len(lines) != 2 {
+		return []aoc.Diagnostic{aoc.NewDiagnostic(1, 1, "found %d lines, expected 2", len(lines))}
 	}
 
-	discriminant := float64(t*t - 4*d)
-	small := math.Ceil((float64(t) - math.Sqrt(discriminant)) / 2)
-	large := math.Floor((float64(t) + math.Sqrt(discriminant)) / 2)
-	ways := int(large) - int(small) + 1
-	println(ways)
+	var diags []aoc.Diagnostic
+	var counts []int
+	for i, prefix := range []string{"Time:", "Distance:"} {
+		s := aoc.NewScanner(i+1, lines[i])
+		s.Literal(prefix)
+		count := 0
+		for s.More() {
+			s.Spaces()
+			s.Int()
+			count++
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		counts = append(counts, count)
+	}
+	if len(diags) == 0 && counts[0] != counts[1] {
+		diags = append(diags, aoc.NewDiagnostic(
+			2, len(lines[1])+1, "found %d distances for %d times", counts[1], counts[0],
+		))
+	}
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      6,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
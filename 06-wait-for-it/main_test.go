@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestWins(t *testing.T) {
+	tests := []struct {
+		time, record string
+		want         int64
+	}{
+		{"7", "9", 4},
+		{"30", "200", 9},
+		// holding for 4 or 6 ties the record, so only 5 beats it
+		{"10", "24", 1},
+		{"10", "25", 0},
+		{"20000000000", "99999999999999999999", 1},
+		// the best distance is 2.25e18, below the record, and 4 times the
+		// record overflows an int64
+		{"3000000000", "5000000000000000000", 0},
+		{"3000000000", "2249999999999999999", 1},
+		{"3000000000", "2250000000000000000", 0},
+	}
+	for _, tt := range tests {
+		tm, record := parseBig(tt.time), parseBig(tt.record)
+		got := int64(0)
+		if lo, hi, ok := Wins(tm, record); ok {
+			got = new(big.Int).Sub(hi, lo).Int64() + 1
+		}
+		if got != tt.want {
+			t.Errorf("Wins(%s, %s) gives %d hold times, want %d", tt.time, tt.record, got, tt.want)
+		}
+		if ways := Ways(Linear{}, tm, record); ways.Int64() != tt.want {
+			t.Errorf("Ways(%s, %s) = %s, want %d", tt.time, tt.record, ways, tt.want)
+		}
+	}
+}
@@ -1,3 +1,7 @@
-module main
+module camel-cards
 
 go 1.21.1
+
+require aoc v0.0.0
+
+replace aoc => ../aoc
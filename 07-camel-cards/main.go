@@ -1,261 +1,523 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"math"
+	"io"
 	"os"
-	"regexp"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"aoc"
 )
 
-var CardStrengths = map[rune]int64{
-	'2': 2,
-	'3': 3,
-	'4': 4,
-	'5': 5,
-	'6': 6,
-	'7': 7,
-	'8': 8,
-	'9': 9,
-	'T': 10,
-	'J': 11,
-	'Q': 12,
-	'K': 13,
-	'A': 14,
+// Ruleset is a house's rules for Camel Cards.
+type Ruleset struct {
+	// Cards lists every card, weakest first.
+	Cards string `json:"cards"`
+	// Wild lists the cards that are played as whatever makes the best
+	// hand. They still break ties by their place in Cards.
+	Wild     string `json:"wild"`
+	HandSize int    `json:"hand_size"`
+	// Types lists the hand types, weakest first. A hand is the strongest
+	// type it can make.
+	Types []TypeRule `json:"types"`
 }
 
-type Type int
+// TypeRule is a hand type: either groups of equal cards the hand must
+// hold, largest first, or a straight of HandSize cards in a row.
+type TypeRule struct {
+	Name     string `json:"name"`
+	Groups   []int  `json:"groups,omitempty"`
+	Straight bool   `json:"straight,omitempty"`
+}
 
-const (
-	Unknown Type = iota
-	HighCard
-	OnePair
-	TwoPair
-	ThreeOfAKind
-	FullHouse
-	FourOfAKind
-	FiveOfAKind
-)
+var standardTypes = []TypeRule{
+	{Name: "High Card", Groups: []int{1}},
+	{Name: "One Pair", Groups: []int{2}},
+	{Name: "Two Pair", Groups: []int{2, 2}},
+	{Name: "Three of a Kind", Groups: []int{3}},
+	{Name: "Full House", Groups: []int{3, 2}},
+	{Name: "Four of a Kind", Groups: []int{4}},
+	{Name: "Five of a Kind", Groups: []int{5}},
+}
 
-func (t Type) String() string {
-	switch t {
-	case Unknown:
-		return "Unknown"
-	case HighCard:
-		return "High Card"
-	case OnePair:
-		return "One Pair"
-	case TwoPair:
-		return "Two Pair"
-	case ThreeOfAKind:
-		return "Three of a Kind"
-	case FullHouse:
-		return "Full House"
-	case FourOfAKind:
-		return "Four of a Kind"
-	case FiveOfAKind:
-		return "Five of a Kind"
-	}
-	return "Unknown"
+// rulesets are the built-in rulesets. Part 1 plays standard, part 2
+// jokers.
+var rulesets = map[string]Ruleset{
+	"standard": {Cards: "23456789TJQKA", HandSize: 5, Types: standardTypes},
+	"jokers":   {Cards: "J23456789TQKA", Wild: "J", HandSize: 5, Types: standardTypes},
 }
 
-var JOKER_MODE = false
+// LoadRuleset returns the built-in ruleset called name, or else reads one
+// from the JSON file name, e.g.
+//
+//	{"cards": "23456789TJQKA", "wild": "2", "hand_size": 5, "types": [
+//		{"name": "High Card", "groups": [1]},
+//		{"name": "One Pair", "groups": [2]},
+//		{"name": "Straight", "straight": true},
+//		{"name": "Four of a Kind", "groups": [4]}
+//	]}
+//
+// Fields left out are taken from the standard ruleset.
+func LoadRuleset(name string) (*Ruleset, error) {
+	if r, ok := rulesets[name]; ok {
+		return r.Check()
+	}
 
-type Hand struct {
-	Cards        string
-	Type         Type
-	Bid          int
-	CardsJokered string
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var r Ruleset
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	standard := rulesets["standard"]
+	if r.Cards == "" {
+		r.Cards = standard.Cards
+	}
+	if r.HandSize == 0 {
+		r.HandSize = standard.HandSize
+	}
+	if r.Types == nil {
+		r.Types = standard.Types
+	}
+	checked, err := r.Check()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return checked, nil
 }
 
-func DetermineType(cards string) (Type, error) {
-	counts := make(map[rune]int)
-	for _, c := range cards {
-		counts[c]++
+// Check returns a copy of the ruleset if it makes sense. Cards are single
+// printable ASCII characters, so a hand's key can hold one byte per card.
+func (r Ruleset) Check() (*Ruleset, error) {
+	if r.Cards == "" {
+		return nil, fmt.Errorf("no cards")
 	}
-
-	var max int
-	for _, v := range counts {
-		if v > max {
-			max = v
+	for i := 0; i < len(r.Cards); i++ {
+		c := r.Cards[i]
+		if c <= ' ' || c > '~' {
+			return nil, fmt.Errorf("card %q is not a printable ASCII character", c)
+		}
+		if strings.IndexByte(r.Cards[:i], c) != -1 {
+			return nil, fmt.Errorf("card %c is listed twice", c)
 		}
 	}
-
-	switch max {
-	case 1:
-		return HighCard, nil
-	case 2:
-		if len(counts) == 4 {
-			return OnePair, nil
+	for i := 0; i < len(r.Wild); i++ {
+		if strings.IndexByte(r.Cards, r.Wild[i]) == -1 {
+			return nil, fmt.Errorf("wild card %c is not a card", r.Wild[i])
+		}
+	}
+	if r.HandSize < 1 {
+		return nil, fmt.Errorf("hand size %d is not positive", r.HandSize)
+	}
+	if len(r.Types) == 0 || len(r.Types) > 255 {
+		return nil, fmt.Errorf("%d types, expected 1 to 255", len(r.Types))
+	}
+	for _, t := range r.Types {
+		if t.Name == "" {
+			return nil, fmt.Errorf("type with no name")
+		}
+		if t.Straight != (len(t.Groups) == 0) {
+			return nil, fmt.Errorf("type %q needs either groups or straight", t.Name)
+		}
+		total := 0
+		for i, g := range t.Groups {
+			if g < 1 || i > 0 && g > t.Groups[i-1] {
+				return nil, fmt.Errorf("type %q: groups must be positive and largest first", t.Name)
+			}
+			total += g
+		}
+		if total > r.HandSize {
+			return nil, fmt.Errorf("type %q needs %d cards, hands have %d", t.Name, total, r.HandSize)
 		}
-		return TwoPair, nil
-	case 3:
-		if len(counts) == 3 {
-			return ThreeOfAKind, nil
+		if t.Straight && r.HandSize > len(r.Cards) {
+			return nil, fmt.Errorf("type %q: a straight of %d needs as many cards", t.Name, r.HandSize)
 		}
-		return FullHouse, nil
-	case 4:
-		return FourOfAKind, nil
-	case 5:
-		return FiveOfAKind, nil
 	}
-	return Unknown, fmt.Errorf("unknown hand type: %s", cards)
+	return &r, nil
 }
 
-func NewHand(c string, bid int) (*Hand, error) {
-	t, err := DetermineType(c)
-	if err != nil {
-		return nil, err
+// WithWild returns the ruleset with the cards in wild made wild and moved
+// below every other card, keeping their order among themselves.
+func (r Ruleset) WithWild(wild string) (*Ruleset, error) {
+	var low, high []byte
+	for i := 0; i < len(r.Cards); i++ {
+		if strings.IndexByte(wild, r.Cards[i]) != -1 {
+			low = append(low, r.Cards[i])
+		} else {
+			high = append(high, r.Cards[i])
+		}
 	}
-	return &Hand{c, t, bid, c}, nil
+	r.Cards = string(low) + string(high)
+	r.Wild = wild
+	return r.Check()
 }
 
-func (h *Hand) String() string {
-	return fmt.Sprintf("%s (jokered=%s, type=%s, bid=%d)", h.Cards, h.CardsJokered, h.Type, h.Bid)
+// Type is a hand's place in its ruleset's types, counting from 1.
+type Type int
+
+func (r *Ruleset) TypeName(t Type) string {
+	if t < 1 || int(t) > len(r.Types) {
+		return "Unknown"
+	}
+	return r.Types[t-1].Name
 }
 
-func (h *Hand) Strength() int64 {
-	strength := int64(math.Pow(16, float64(5))) * int64(h.Type)
-	for i, c := range h.Cards {
-		s := CardStrengths[c]
-		if JOKER_MODE && c == 'J' {
-			s = 1
+func (r *Ruleset) strength(c byte) int {
+	return strings.IndexByte(r.Cards, c)
+}
+
+func (r *Ruleset) isWild(c byte) bool {
+	return strings.IndexByte(r.Wild, c) != -1
+}
+
+type group struct {
+	card byte
+	n    int
+}
+
+// histogram groups the cards of a hand other than wildcards, largest and
+// then strongest first, and counts the wildcards.
+func (r *Ruleset) histogram(cards string) (groups []group, wildcards int) {
+	for i := 0; i < len(cards); i++ {
+		c := cards[i]
+		if r.isWild(c) {
+			wildcards++
+			continue
 		}
-		strength += int64(math.Pow(16, float64(4-i))) * s
+		j := slices.IndexFunc(groups, func(g group) bool { return g.card == c })
+		if j == -1 {
+			groups = append(groups, group{c, 0})
+			j = len(groups) - 1
+		}
+		groups[j].n++
 	}
-	return strength
+	slices.SortFunc(groups, func(a, b group) int {
+		if a.n != b.n {
+			return b.n - a.n
+		}
+		return r.strength(b.card) - r.strength(a.card)
+	})
+	return groups, wildcards
 }
 
-func nextCartesian(a []string, r int) func() []string {
-	p := make([]string, r)
-	x := make([]int, len(p))
-	return func() []string {
-		p := p[:len(x)]
-		for i, xi := range x {
-			p[i] = a[xi]
+// play returns what the wildcards are played as to make a hand of type t,
+// or false if the hand cannot be one.
+func (r *Ruleset) play(t TypeRule, groups []group, wildcards int) ([]byte, bool) {
+	var plays []byte
+	if t.Straight {
+		for _, g := range groups {
+			if g.n > 1 {
+				return nil, false
+			}
 		}
-		for i := len(x) - 1; i >= 0; i-- {
-			x[i]++
-			if x[i] < len(a) {
-				break
+		// the strongest run of cards holding all of the hand's own
+		for start := len(r.Cards) - r.HandSize; start >= 0; start-- {
+			run := r.Cards[start : start+r.HandSize]
+			plays = plays[:0]
+			for i := 0; i < len(run); i++ {
+				if !slices.ContainsFunc(groups, func(g group) bool { return g.card == run[i] }) {
+					plays = append(plays, run[i])
+				}
 			}
-			x[i] = 0
-			if i <= 0 {
-				x = x[0:0]
-				break
+			if len(plays) == wildcards {
+				return plays, true
 			}
 		}
-		return p
+		return nil, false
 	}
-}
-
-var JokerPattern = regexp.MustCompile("J")
 
-func (h *Hand) UseJokers() {
-	indices := JokerPattern.FindAllStringIndex(h.Cards, -1)
-
-	nc := nextCartesian(
-		[]string{"A", "K", "Q", "T", "9", "8", "7", "6", "5", "4", "3", "2"},
-		len(indices),
-	)
-	for {
-		sub := nc()
-		if len(sub) == 0 {
-			break
+	// the largest groups are the cheapest to grow, and any groups the hand
+	// lacks altogether are made of the strongest cards it does not hold
+	used := make([]byte, 0, len(groups))
+	for _, g := range groups {
+		used = append(used, g.card)
+	}
+	for i, want := range t.Groups {
+		var g group
+		if i < len(groups) {
+			g = groups[i]
+		} else {
+			for j := len(r.Cards) - 1; j >= 0; j-- {
+				if !slices.Contains(used, r.Cards[j]) {
+					g.card = r.Cards[j]
+					break
+				}
+			}
+			if g.card == 0 {
+				return nil, false
+			}
+			used = append(used, g.card)
 		}
+		for n := g.n; n < want; n++ {
+			plays = append(plays, g.card)
+		}
+	}
+	if len(plays) > wildcards {
+		return nil, false
+	}
+	// any wildcards left over join the largest group
+	for len(plays) < wildcards {
+		plays = append(plays, used[0])
+	}
+	return plays, true
+}
 
-		cards := h.Cards
-		for i, index := range indices {
-			cards = cards[:index[0]] + sub[i] + cards[index[1]:]
+// Classify returns the strongest type the cards can make, and the cards
+// with each wildcard replaced by what it is played as.
+func (r *Ruleset) Classify(cards string) (Type, string, error) {
+	if len(cards) != r.HandSize {
+		return 0, "", fmt.Errorf("hand %s has %d cards, expected %d", cards, len(cards), r.HandSize)
+	}
+	for i := 0; i < len(cards); i++ {
+		if r.strength(cards[i]) == -1 {
+			return 0, "", fmt.Errorf("hand %s: unknown card %c", cards, cards[i])
 		}
-		t, err := DetermineType(cards)
-		if err != nil {
-			panic(err)
+	}
+
+	groups, wildcards := r.histogram(cards)
+	for t := len(r.Types) - 1; t >= 0; t-- {
+		plays, ok := r.play(r.Types[t], groups, wildcards)
+		if !ok {
+			continue
 		}
-		new := &Hand{cards, t, h.Bid, cards}
-		if new.Strength() > h.Strength() {
-			h.CardsJokered = cards
-			h.Type = t
+		played := []byte(cards)
+		for i := range played {
+			if r.isWild(played[i]) {
+				played[i], plays = plays[0], plays[1:]
+			}
 		}
+		return Type(t + 1), string(played), nil
 	}
+	return 0, "", fmt.Errorf("unknown hand type: %s", cards)
+}
+
+type Hand struct {
+	Cards        string
+	Type         Type
+	Bid          int
+	CardsJokered string
+	// Natural is the type of the cards with any wildcards played as
+	// themselves, 0 if none of the ruleset's types fits.
+	Natural Type
+	// Key orders hands by type, then card by card.
+	Key   string
+	Rules *Ruleset
 }
 
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
+func (r *Ruleset) NewHand(cards string, bid int) (*Hand, error) {
+	t, jokered, err := r.Classify(cards)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	natural := *r
+	natural.Wild = ""
+	nt, _, _ := natural.Classify(cards)
+
+	key := make([]byte, 0, 1+len(cards))
+	key = append(key, byte(t))
+	for i := 0; i < len(cards); i++ {
+		key = append(key, byte(r.strength(cards[i])))
 	}
+	return &Hand{
+		Cards:        cards,
+		Type:         t,
+		Bid:          bid,
+		CardsJokered: jokered,
+		Natural:      nt,
+		Key:          string(key),
+		Rules:        r,
+	}, nil
+}
+
+func (h *Hand) String() string {
+	return fmt.Sprintf("%s (jokered=%s, type=%s, bid=%d)", h.Cards, h.CardsJokered, h.Rules.TypeName(h.Type), h.Bid)
+}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
+// CompareHands orders hands from weakest to strongest.
+func CompareHands(a, b *Hand) int {
+	return strings.Compare(a.Key, b.Key)
 }
 
-func part1() {
-	JOKER_MODE = false
-	lines := ReadLines("input.txt")
+func ParseHands(r *Ruleset, lines []string) []*Hand {
 	var hands []*Hand
 	for _, line := range lines {
-		split := strings.Split(line, " ")
+		split := strings.Fields(line)
 
 		bid, err := strconv.Atoi(split[1])
 		if err != nil {
 			panic(err)
 		}
 
-		hand, err := NewHand(split[0], bid)
+		hand, err := r.NewHand(split[0], bid)
 		if err != nil {
 			panic(err)
 		}
 		hands = append(hands, hand)
 	}
+	return hands
+}
 
-	// sort by ascending order
-	sort.Slice(hands, func(i, j int) bool {
-		return hands[i].Strength() < hands[j].Strength()
-	})
+// Winnings ranks the hands and adds up each bid times its rank.
+func Winnings(hands []*Hand) int {
+	slices.SortFunc(hands, CompareHands)
 	winnings := 0
 	for i, hand := range hands {
 		winnings += hand.Bid * (i + 1)
 	}
-	println(winnings)
+	return winnings
 }
 
-func part2() {
-	JOKER_MODE = true
-	lines := ReadLines("input.txt")
-	var hands []*Hand
-	for _, line := range lines {
-		split := strings.Split(line, " ")
+var (
+	rulesFlag = flag.String("rules", "", "score every part with this ruleset, built-in or a JSON `file`")
+	wild      = flag.String("wild", "J", "cards that are wild in part 2, unless -rules is given")
 
-		bid, err := strconv.Atoi(split[1])
-		if err != nil {
-			panic(err)
-		}
+	customRules = sync.OnceValues(func() (*Ruleset, error) {
+		return LoadRuleset(*rulesFlag)
+	})
+)
 
-		hand, err := NewHand(split[0], bid)
-		if err != nil {
-			panic(err)
+// rules returns the ruleset for -rules if it was given, or else the
+// standard rules with the -wild cards wild in part 2.
+func rules(part int) (*Ruleset, error) {
+	if *rulesFlag != "" {
+		return customRules()
+	}
+	if part == 2 {
+		return rulesets["standard"].WithWild(*wild)
+	}
+	return rulesets["standard"].Check()
+}
+
+func solve(part int, lines []string) int {
+	r, err := rules(part)
+	if err != nil {
+		panic(err)
+	}
+	return Winnings(ParseHands(r, lines))
+}
+
+func part1(lines []string) any {
+	return solve(1, lines)
+}
+
+func part2(lines []string) any {
+	return solve(2, lines)
+}
+
+var csvFlag = flag.String("csv", "", "write the report's `table` as CSV: hands or types")
+
+// report lists the hands from weakest to strongest with their types
+// before and after wildcards are played, their rank and what they win,
+// then how many hands are of each type and what they win between them.
+func report(w io.Writer, part int, lines []string) error {
+	r, err := rules(part)
+	if err != nil {
+		return err
+	}
+	hands := ParseHands(r, lines)
+	total := Winnings(hands)
+
+	type tally struct{ before, after, winnings int }
+	types := make([]tally, len(r.Types)+1)
+	for i, h := range hands {
+		types[h.Natural].before++
+		types[h.Type].after++
+		types[h.Type].winnings += h.Bid * (i + 1)
+	}
+
+	switch *csvFlag {
+	case "hands":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"rank", "hand", "bid", "type", "played_as", "joker_type", "winnings"})
+		for i, h := range hands {
+			cw.Write([]string{
+				strconv.Itoa(i + 1), h.Cards, strconv.Itoa(h.Bid), r.TypeName(h.Natural),
+				h.CardsJokered, r.TypeName(h.Type), strconv.Itoa(h.Bid * (i + 1)),
+			})
 		}
-		hands = append(hands, hand)
+		cw.Flush()
+		return cw.Error()
+	case "types":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"type", "before", "after", "winnings"})
+		for t, n := range types {
+			if t > 0 || n.before > 0 {
+				cw.Write([]string{
+					r.TypeName(Type(t)), strconv.Itoa(n.before), strconv.Itoa(n.after), strconv.Itoa(n.winnings),
+				})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "":
+	default:
+		return fmt.Errorf("unknown -csv table %q, expected hands or types", *csvFlag)
 	}
 
-	for _, hand := range hands {
-		hand.UseJokers()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tHAND\tBID\tTYPE\tPLAYED AS\tJOKER TYPE\tWINNINGS")
+	for i, h := range hands {
+		fmt.Fprintf(
+			tw, "%d\t%s\t%d\t%s\t%s\t%s\t%d\n",
+			i+1, h.Cards, h.Bid, r.TypeName(h.Natural), h.CardsJokered, r.TypeName(h.Type), h.Bid*(i+1),
+		)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t\t\t%d\n\n", total)
+	if err := tw.Flush(); err != nil {
+		return err
 	}
 
-	// sort by ascending order
-	sort.Slice(hands, func(i, j int) bool {
-		return hands[i].Strength() < hands[j].Strength()
-	})
-	winnings := 0
-	for i, hand := range hands {
-		winnings += hand.Bid * (i + 1)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tBEFORE\tAFTER\tWINNINGS")
+	for t, n := range types {
+		// hands no type fits only show before their wildcards are played
+		if t > 0 || n.before > 0 {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", r.TypeName(Type(t)), n.before, n.after, n.winnings)
+		}
 	}
-	println(winnings)
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\n", len(hands), len(hands), total)
+	return tw.Flush()
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	// both parts play the same cards
+	r, err := rules(1)
+	if err != nil {
+		return []aoc.Diagnostic{aoc.NewDiagnostic(1, 1, "%v", err)}
+	}
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		cards, _ := s.Chars(r.Cards, "card")
+		s.Literal(" ")
+		s.Int()
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if s.Ok() && len(cards) != r.HandSize {
+			diags = append(diags, aoc.NewDiagnostic(i+1, 1, "hand has %d cards, expected %d", len(cards), r.HandSize))
+		}
+	}
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      7,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+		Report:   report,
+	})
 }
@@ -1,19 +1,17 @@
 package main
 
 import (
+	"cmp"
+	"errors"
+	"flag"
 	"fmt"
-	"os"
+	"math"
+	"regexp"
+	"slices"
 	"strings"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Node struct {
 	Value string
@@ -47,38 +45,168 @@ func (g *Graph) GetNode(value string) *Node {
 	return g.Nodes[value]
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+// nameChars are the characters node names are made of: letters, digits
+// and any punctuation but that of a node line.
+var nameChars = aoc.Upper + aoc.Lower + aoc.Digits + strings.Map(func(r rune) rune {
+	if strings.ContainsRune("=(),", r) {
+		return -1
+	}
+	return r
+}, aoc.Punct)
+
+func skipSpace(s *aoc.Scanner) {
+	for s.Accept(" ") || s.Accept("\t") {
+	}
+}
+
+func scanInstructions(s *aoc.Scanner) string {
+	skipSpace(s)
+	instructions, _ := s.Chars("LR", "direction")
+	skipSpace(s)
+	s.End()
+	return instructions
+}
+
+// scanNode reads a node line such as "AAA = (BBB, CCC)", returning the
+// three names and their columns. Names may be of any length, and there
+// may be any spacing around them.
+func scanNode(s *aoc.Scanner) (names [3]string, cols [3]int) {
+	name := func(i int) {
+		skipSpace(s)
+		cols[i] = s.Col()
+		names[i], _ = s.Chars(nameChars, "node name")
+	}
+	literal := func(lit string) {
+		skipSpace(s)
+		s.Literal(lit)
+	}
+	name(0)
+	literal("=")
+	literal("(")
+	name(1)
+	literal(",")
+	name(2)
+	literal(")")
+	skipSpace(s)
+	s.End()
+	return names, cols
+}
 
-	instruction := lines[0]
+// ParseNetwork reads the instructions and the graph of nodes. Blank lines
+// are skipped wherever they are.
+func ParseNetwork(lines []string) (string, *Graph, error) {
+	var instructions string
 	g := NewGraph()
-	for _, line := range lines[2:] {
-		if line == "" {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		s := aoc.NewScanner(i+1, line)
+		if instructions == "" {
+			instructions = scanInstructions(s)
+		} else if names, _ := scanNode(s); s.Ok() {
+			if g.GetNode(names[0]) != nil {
+				return "", nil, fmt.Errorf("line %d: node %s defined twice", i+1, names[0])
+			}
+			g.AddNode(names[0], names[1], names[2])
+		}
+		if diags := s.Diagnostics(); len(diags) > 0 {
+			return "", nil, errors.New(diags[0].String())
+		}
+	}
+	if instructions == "" {
+		return "", nil, errors.New("no instructions")
+	}
+	for _, node := range g.Nodes {
+		for _, next := range []string{node.Left, node.Right} {
+			if g.GetNode(next) == nil {
+				return "", nil, fmt.Errorf("node %s leads to %s, which is not defined", node.Value, next)
+			}
+		}
+	}
+	return instructions, g, nil
+}
 
-		value := line[:3]
-		left := line[7:10]
-		right := line[12:15]
+// Predicate picks out nodes by name.
+type Predicate func(name string) bool
 
-		g.AddNode(value, left, right)
+// ParsePredicate reads name:AAA,BBB for exact names, suffix:Z for the
+// names ending in Z, or regex:RE for the names RE matches.
+func ParsePredicate(spec string) (Predicate, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "name":
+		names := strings.Split(arg, ",")
+		return func(name string) bool { return slices.Contains(names, name) }, nil
+	case "suffix":
+		return func(name string) bool { return strings.HasSuffix(name, arg) }, nil
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("predicate %q: %v", spec, err)
+		}
+		return re.MatchString, nil
 	}
+	return nil, fmt.Errorf("unknown predicate %q, expected name:, suffix: or regex:", spec)
+}
 
-	cur := "AAA"
-	steps := 0
-	for {
-		direction := instruction[steps%len(instruction)]
-		if direction == 'L' {
-			cur = g.GetNode(cur).Left
-		} else {
-			cur = g.GetNode(cur).Right
+// Walk is the path of a ghost from its start node. After Start steps it
+// is in a cycle of Period steps through the same nodes at the same
+// places in the instructions, which it follows forever. Hits are the
+// steps at which it is on an end node.
+type Walk struct {
+	Start, Period int
+	// Prefix lists the hits before the cycle, Offsets those in its first
+	// round. Each offset o is hit again at o + k*Period.
+	Prefix, Offsets []int
+}
+
+// Walk follows the instructions from start until it comes back to a node
+// at the same place in the instructions.
+func (g *Graph) Walk(instructions, start string, isEnd func(string) bool) *Walk {
+	type state struct {
+		node string
+		i    int
+	}
+	seen := make(map[state]int)
+	var hits []int
+	cur := start
+	for steps := 0; ; steps++ {
+		s := state{cur, steps % len(instructions)}
+		if first, ok := seen[s]; ok {
+			w := &Walk{Start: first, Period: steps - first}
+			for _, hit := range hits {
+				if hit < first {
+					w.Prefix = append(w.Prefix, hit)
+				} else {
+					w.Offsets = append(w.Offsets, hit)
+				}
+			}
+			return w
 		}
-		steps += 1
-		if cur == "ZZZ" {
-			break
+		seen[s] = steps
+		if isEnd(cur) {
+			hits = append(hits, steps)
+		}
+
+		node := g.GetNode(cur)
+		if node == nil {
+			panic("no such node: " + cur)
+		}
+		if instructions[s.i] == 'L' {
+			cur = node.Left
+		} else {
+			cur = node.Right
 		}
 	}
-	println(steps)
+}
+
+// Hits reports whether the ghost is on an end node after steps steps.
+func (w *Walk) Hits(steps int) bool {
+	if steps < w.Start {
+		return slices.Contains(w.Prefix, steps)
+	}
+	return slices.Contains(w.Offsets, w.Start+(steps-w.Start)%w.Period)
 }
 
 func GCD(a, b int) int {
@@ -90,64 +218,237 @@ func GCD(a, b int) int {
 	return a
 }
 
-func LCM(a, b int, integers ...int) int {
-	result := a * b / GCD(a, b)
+// congruence is the steps t with t = R (mod M).
+type congruence struct {
+	R, M int
+}
 
-	for i := 0; i < len(integers); i++ {
-		result = LCM(result, integers[i])
+// CRT returns the steps that are in both congruences, and false if there
+// are none. The moduli need not be coprime.
+func CRT(a, b congruence) (congruence, bool) {
+	g := GCD(a.M, b.M)
+	if (b.R-a.R)%g != 0 {
+		return congruence{}, false
+	}
+	if a.M/g > math.MaxInt/b.M {
+		panic("cycles too long: their combined period overflows int")
 	}
+	m := a.M / g * b.M
 
-	return result
+	// a.R + a.M*k = b.R (mod b.M), so k = (b.R-a.R)/g / (a.M/g) (mod b.M/g)
+	mod := b.M / g
+	k := (b.R - a.R) / g % mod * inverse(a.M/g%mod, mod) % mod
+	r := (a.R + a.M*k) % m
+	if r < 0 {
+		r += m
+	}
+	return congruence{r, m}, true
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+// inverse returns the inverse of a modulo m, which must be coprime to it.
+func inverse(a, m int) int {
+	x, lastX, r, lastR := 0, 1, m, a
+	for r != 0 {
+		q := lastR / r
+		lastR, r = r, lastR-q*r
+		lastX, x = x, lastX-q*x
+	}
+	return (lastX%m + m) % m
+}
 
-	instruction := lines[0]
-	g := NewGraph()
-	for _, line := range lines[2:] {
-		if line == "" {
-			continue
+// Meet returns the first step, from 1 on, at which every walk is on an
+// end node, and false if that never happens.
+func Meet(walks []*Walk) (int, bool) {
+	start := 1
+	for _, w := range walks {
+		start = max(start, w.Start)
+	}
+	all := func(steps int) bool {
+		for _, w := range walks {
+			if !w.Hits(steps) {
+				return false
+			}
 		}
+		return true
+	}
 
-		value := line[:3]
-		left := line[7:10]
-		right := line[12:15]
+	// before start some walk has yet to reach its cycle, so the ghosts can
+	// only meet at one of its prefix hits
+	best, found := 0, false
+	for _, w := range walks {
+		for _, steps := range w.Prefix {
+			if steps > 0 && steps < start && (!found || steps < best) && all(steps) {
+				best, found = steps, true
+			}
+		}
+	}
+	if found {
+		return best, true
+	}
 
-		g.AddNode(value, left, right)
+	// from then on every walk is in its cycle
+	meets := []congruence{{0, 1}}
+	for _, w := range walks {
+		var next []congruence
+		for _, c := range meets {
+			for _, offset := range w.Offsets {
+				if both, ok := CRT(c, congruence{offset % w.Period, w.Period}); ok {
+					next = append(next, both)
+				}
+			}
+		}
+		slices.SortFunc(next, func(a, b congruence) int { return cmp.Compare(a.R, b.R) })
+		meets = slices.Compact(next)
+	}
+	for _, c := range meets {
+		steps := start + ((c.R-start)%c.M+c.M)%c.M
+		if !found || steps < best {
+			best, found = steps, true
+		}
 	}
+	return best, found
+}
 
-	var nodes []string
-	for _, node := range g.Nodes {
-		if node.Value[2] == 'A' {
-			nodes = append(nodes, node.Value)
+var (
+	startFlag = flag.String("start", "", "`nodes` to start from in every part: name:A,B, suffix:S or regex:RE")
+	endFlag   = flag.String("end", "", "`nodes` to end on in every part: name:A,B, suffix:S or regex:RE")
+)
+
+// specs returns the start and end predicates of a part as given by -start
+// and -end, or else the puzzle's.
+func specs(part int) (start, end string) {
+	start, end = "name:AAA", "name:ZZZ"
+	if part == 2 {
+		start, end = "suffix:A", "suffix:Z"
+	}
+	if *startFlag != "" {
+		start = *startFlag
+	}
+	if *endFlag != "" {
+		end = *endFlag
+	}
+	return start, end
+}
+
+func solve(part int, lines []string) int {
+	instructions, g, err := ParseNetwork(lines)
+	if err != nil {
+		panic(err)
+	}
+	startSpec, endSpec := specs(part)
+	isStart, err := ParsePredicate(startSpec)
+	if err != nil {
+		panic(err)
+	}
+	isEnd, err := ParsePredicate(endSpec)
+	if err != nil {
+		panic(err)
+	}
+
+	var walks []*Walk
+	for name := range g.Nodes {
+		if isStart(name) {
+			walks = append(walks, g.Walk(instructions, name, isEnd))
+		}
+	}
+	if len(walks) == 0 {
+		panic("no node matches " + startSpec)
+	}
+	steps, ok := Meet(walks)
+	if !ok {
+		panic("the ghosts are never all on end nodes at once")
+	}
+	return steps
+}
+
+func part1(lines []string) any {
+	return solve(1, lines)
+}
+
+func part2(lines []string) any {
+	return solve(2, lines)
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	type ref struct {
+		name      string
+		line, col int
+	}
+	defined := make(map[string]bool)
+	var refs []ref
+	first, instructions := 0, false
+	for i, line := range lines {
+		num := i + 1
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		s := aoc.NewScanner(num, line)
+		if !instructions {
+			scanInstructions(s)
+			diags = append(diags, s.Diagnostics()...)
+			instructions = true
+			continue
+		}
+		names, cols := scanNode(s)
+		diags = append(diags, s.Diagnostics()...)
+		if first == 0 {
+			first = num
 		}
+		if !s.Ok() {
+			continue
+		}
+
+		if defined[names[0]] {
+			diags = append(diags, aoc.NewDiagnostic(num, cols[0], "node %s defined twice", names[0]))
+		}
+		defined[names[0]] = true
+		for j := 1; j < 3; j++ {
+			refs = append(refs, ref{names[j], num, cols[j]})
+		}
+	}
+	if !instructions {
+		return append(diags, aoc.NewDiagnostic(1, 1, "no instructions"))
+	}
+	if first == 0 {
+		return append(diags, aoc.NewDiagnostic(len(lines), 1, "no nodes"))
 	}
 
-	var minSteps []int
-	for _, cur := range nodes {
-		steps := 0
-		for {
-			direction := instruction[steps%len(instruction)]
-			// fmt.Printf("Node: %s, direction: %c\n", cur, direction)
-			if direction == 'L' {
-				cur = g.GetNode(cur).Left
-			} else {
-				cur = g.GetNode(cur).Right
+	for _, r := range refs {
+		if !defined[r.name] {
+			diags = append(diags, aoc.NewDiagnostic(r.line, r.col, "node %s is not defined", r.name))
+		}
+	}
+	checked := make(map[string]bool)
+	for _, part := range []int{1, 2} {
+		start, end := specs(part)
+		for _, spec := range []string{start, end} {
+			if checked[spec] {
+				continue
 			}
-			steps += 1
-			if cur[2] == 'Z' {
-				break
+			checked[spec] = true
+			match, err := ParsePredicate(spec)
+			if err != nil {
+				diags = append(diags, aoc.NewDiagnostic(1, 1, "%v", err))
+				continue
+			}
+			found := false
+			for name := range defined {
+				found = found || match(name)
+			}
+			if !found {
+				diags = append(diags, aoc.NewDiagnostic(first, 1, "no node matches %s", spec))
 			}
 		}
-		minSteps = append(minSteps, steps)
 	}
-
-	lcm := LCM(minSteps[0], minSteps[1], minSteps...)
-	println(lcm)
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      8,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -1,19 +1,11 @@
 package main
 
 import (
-	"os"
 	"strconv"
 	"strings"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 func ParseNums(line string) []int {
 	nums := []int{}
@@ -75,29 +67,46 @@ func PredictPrev(nums []int) int {
 	return nums[0] + sub
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	total := 0
 	for _, line := range lines {
 		parsed := ParseNums(line)
 		next := PredictNext(parsed)
 		total += next
 	}
-	println(total)
+	return total
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	total := 0
 	for _, line := range lines {
 		parsed := ParseNums(line)
 		prev := PredictPrev(parsed)
 		total += prev
 	}
-	println(total)
+	return total
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.SignedInt()
+		for s.More() {
+			s.Literal(" ")
+			s.SignedInt()
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+	}
+	return diags
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      9,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -2,19 +2,10 @@ package main
 
 import (
 	"math"
-	"os"
 	"slices"
-	"strings"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Maze struct {
 	Maze []string
@@ -199,9 +190,7 @@ func (g *Game) Move() {
 	panic("No valid move")
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
-
+func part1(lines []string) any {
 	maze := NewMaze(lines)
 	game := NewGame(maze)
 
@@ -212,12 +201,10 @@ func part1() {
 		}
 	}
 	farthest := int(math.Ceil(float64(game.Steps) / 2))
-	println(farthest)
+	return farthest
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
-
+func part2(lines []string) any {
 	maze := NewMaze(lines)
 	game := NewGame(maze)
 
@@ -291,11 +278,19 @@ func part2() {
 			}
 		}
 	}
-	println(area)
+	return area
+}
 
+func validate(lines []string) []aoc.Diagnostic {
+	diags := aoc.CheckGrid(lines, "|-LJ7F.S")
+	return append(diags, aoc.CheckCount(lines, 'S', 1)...)
 }
 
 func main() {
-	part1()
-	part2()
+	aoc.Main(aoc.Puzzle{
+		Day:      10,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
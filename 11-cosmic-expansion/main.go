@@ -2,18 +2,10 @@ package main
 
 import (
 	"math"
-	"os"
 	"strings"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Universe struct {
 	data []string
@@ -127,21 +119,38 @@ func GeneratePairs(coords []Coord) [][]Coord {
 	return pairs
 }
 
-func main() {
-	lines := ReadLines("input.txt")
+func Solve(lines []string, expansion int) int {
 	u := NewUniverse(lines)
 	emptyRows := u.EmptyRows()
 	emptyCols := u.EmptyCols()
 
 	galaxies := u.Galaxies()
 	pairs := GeneratePairs(galaxies)
-	part1 := 0
-	part2 := 0
+	total := 0
 	for _, pair := range pairs {
 		path := ShortestPaths(u, pair[0], pair[1], emptyRows, emptyCols)
-		part1 += path.steps + path.empties*(2-1)
-		part2 += path.steps + path.empties*(1e6-1)
+		total += path.steps + path.empties*(expansion-1)
 	}
-	println(part1)
-	println(part2)
+	return total
+}
+
+func part1(lines []string) any {
+	return Solve(lines, 2)
+}
+
+func part2(lines []string) any {
+	return Solve(lines, 1e6)
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	return aoc.CheckGrid(lines, ".#")
+}
+
+func main() {
+	aoc.Main(aoc.Puzzle{
+		Day:      11,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
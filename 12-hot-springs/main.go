@@ -1,21 +1,11 @@
 package main
 
 import (
-	"fmt"
-	"os"
 	"strconv"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 func SplitNums(line string) []int {
 	var result []int
@@ -94,8 +84,7 @@ func CountArrangements(conditions string, groups []int) int {
 	}
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	ways := 0
 	for _, line := range lines {
 		split := strings.Split(line, " ")
@@ -104,7 +93,7 @@ func part1() {
 		counts := CountArrangements(conditions, groups)
 		ways += counts
 	}
-	println(ways)
+	return ways
 }
 
 func repeatSlice[T any](s []T, n int) []T {
@@ -115,8 +104,7 @@ func repeatSlice[T any](s []T, n int) []T {
 	return arr
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	ways := 0
 	for _, line := range lines {
 		split := strings.Split(line, " ")
@@ -130,16 +118,30 @@ func part2() {
 		counts := CountArrangements(conditions, groups)
 		ways += counts
 	}
-	println(ways)
+	return ways
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.Chars(".#?", "spring")
+		s.Literal(" ")
+		s.Int()
+		for s.Accept(",") {
+			s.Int()
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      12,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
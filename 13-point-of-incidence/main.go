@@ -1,20 +1,10 @@
 package main
 
 import (
-	"fmt"
 	"math/bits"
-	"os"
-	"strings"
-	"time"
-)
 
-func ReadFile(filename string) string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return ""
-	}
-	return string(content)
-}
+	"aoc"
+)
 
 func TranposeLines(lines []string) []string {
 	var transposed []string
@@ -77,44 +67,49 @@ func FindMirror(arr []int, smudge bool) (int, bool) {
 	return 0, false
 }
 
-func Solve(smudge bool) {
-	content := ReadFile("input.txt")
-	puzzles := strings.Split(strings.TrimSpace(content), "\n\n")
+func Solve(lines []string, smudge bool) int {
+	puzzles, _ := aoc.Blocks(lines)
 
 	summary := 0
 	for _, puzzle := range puzzles {
-		lines := strings.Split(strings.TrimSpace(puzzle), "\n")
-		rows := EncodeLines(lines)
+		rows := EncodeLines(puzzle)
 		mr, ok := FindMirror(rows, smudge)
 		if ok {
 			summary += mr * 100
 		}
 
-		transposed := TranposeLines(lines)
+		transposed := TranposeLines(puzzle)
 		cols := EncodeLines(transposed)
 		mc, ok := FindMirror(cols, smudge)
 		if ok {
 			summary += mc
 		}
 	}
-	println(summary)
+	return summary
 }
 
-func part1() {
-	Solve(false)
+func part1(lines []string) any {
+	return Solve(lines, false)
 }
 
-func part2() {
-	Solve(true)
+func part2(lines []string) any {
+	return Solve(lines, true)
 }
 
-func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	blocks, starts := aoc.Blocks(lines)
+	for i, block := range blocks {
+		diags = append(diags, aoc.ShiftDiagnostics(aoc.CheckGrid(block, ".#"), starts[i])...)
+	}
+	return diags
+}
 
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+func main() {
+	aoc.Main(aoc.Puzzle{
+		Day:      13,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
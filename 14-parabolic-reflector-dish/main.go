@@ -1,21 +1,11 @@
 package main
 
 import (
-	"fmt"
-	"os"
 	"slices"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 func FormatLines(lines []string) string {
 	return strings.Join(lines, "\n")
@@ -123,18 +113,15 @@ func ScorePlatform(lines []string) int {
 	return score
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	lines = RotateClockwise(lines)
 	lines = RollPlatform(lines)
 	lines = RotateAnticlockwise(lines)
 	score := ScorePlatform(lines)
-	println(score)
+	return score
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
-
+func part2(lines []string) any {
 	var cycles []string
 	var scores []int
 	n := 1000000000
@@ -152,16 +139,18 @@ func part2() {
 		scores = append(scores, ScorePlatform(lines))
 	}
 	index := (n-loopStart)%(i-loopStart+1) + loopStart
-	println(scores[index])
+	return scores[index]
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	return aoc.CheckGrid(lines, ".#O")
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      14,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
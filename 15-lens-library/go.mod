@@ -1,3 +1,7 @@
-module main
+module lens-library
 
 go 1.21.1
+
+require aoc v0.0.0
+
+replace aoc => ../aoc
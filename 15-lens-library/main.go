@@ -1,22 +1,13 @@
 package main
 
 import (
-	"fmt"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
-)
 
-func ReadFile(filename string) string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		panic(err)
-	}
-	return string(content)
-}
+	"aoc"
+)
 
 func HashChar(char rune, cur int) int {
 	cur += int(char)
@@ -33,15 +24,15 @@ func HashString(s string) int {
 	return cur
 }
 
-func part1() {
-	line := ReadFile("input.txt")
+func part1(lines []string) any {
+	line := lines[0]
 	strs := strings.Split(line, ",")
 
 	total := 0
 	for _, str := range strs {
 		total += HashString(str)
 	}
-	println(total)
+	return total
 }
 
 type Lens struct {
@@ -51,8 +42,8 @@ type Lens struct {
 	i           int
 }
 
-func part2() {
-	line := ReadFile("input.txt")
+func part2(lines []string) any {
+	line := lines[0]
 	strs := strings.Split(line, ",")
 	pattern := regexp.MustCompile(`([a-z]+)(=|-)([0-9]*)`)
 
@@ -97,16 +88,36 @@ func part2() {
 			total += (b + 1) * (i + 1) * lens.focalLength
 		}
 	}
-	println(total)
+	return total
 }
 
-func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	s := aoc.NewScanner(1, lines[0])
+	for {
+		s.Word()
+		if s.Accept("=") {
+			s.Char("123456789", "focal length")
+		} else {
+			s.Literal("-")
+		}
+		if !s.Accept(",") {
+			break
+		}
+	}
+	s.End()
+	diags = append(diags, s.Diagnostics()...)
+	if len(lines) > 1 {
+		diags = append(diags, aoc.NewDiagnostic(2, 1, "expected the sequence on a single line"))
+	}
+	return diags
+}
 
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+func main() {
+	aoc.Main(aoc.Puzzle{
+		Day:      15,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
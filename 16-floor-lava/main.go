@@ -1,20 +1,6 @@
 package main
 
-import (
-	"fmt"
-	"os"
-	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
-
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+import "aoc"
 
 type Grid []string
 
@@ -135,15 +121,13 @@ func BFS(g Grid, start Beam) int {
 	return len(energized)
 }
 
-func part1() {
-	grid := ReadLines("input.txt")
+func part1(grid []string) any {
 	start := Beam{Coord{0, 0}, Right}
 	num := BFS(grid, start)
-	fmt.Println(num)
+	return num
 }
 
-func part2() {
-	grid := ReadLines("input.txt")
+func part2(grid []string) any {
 	h := len(grid)
 	w := len(grid[0])
 	maximum := 0
@@ -171,16 +155,18 @@ func part2() {
 			}
 		}
 	}
-	println(maximum)
+	return maximum
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	return aoc.CheckGrid(lines, `./\|-`)
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      16,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
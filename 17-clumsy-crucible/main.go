@@ -3,20 +3,11 @@ package main
 import (
 	"container/heap"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 func ParseNums(line string) []int {
 	nums := []int{}
@@ -201,29 +192,29 @@ func Dijkstra(grid [][]int, source, dest Coord, minSteps, maxSteps int) int {
 	return minimum
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	grid := BuildGrid(lines)
 	h, w := len(grid), len(grid[0])
 	cost := Dijkstra(grid, Coord{0, 0}, Coord{h - 1, w - 1}, 1, 3)
-	fmt.Println(cost)
+	return cost
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	grid := BuildGrid(lines)
 	h, w := len(grid), len(grid[0])
 	cost := Dijkstra(grid, Coord{0, 0}, Coord{h - 1, w - 1}, 4, 10)
-	fmt.Println(cost)
+	return cost
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	return aoc.CheckGrid(lines, "123456789")
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      17,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -2,20 +2,11 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Direction string
 
@@ -134,33 +125,76 @@ func ShoelaceArea(coords []Coord) int {
 	return area / 2
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	plan := NewPlan(lines, false)
 	coords := GetCoordList(plan)
 	area := ShoelaceArea(coords)
 	length := plan.TotalLength()
 	totalArea := area + length/2 + 1
-	fmt.Println(totalArea)
+	return totalArea
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	plan := NewPlan(lines, true)
 	coords := GetCoordList(plan)
 	area := ShoelaceArea(coords)
 	length := plan.TotalLength()
 	totalArea := area + length/2 + 1
-	fmt.Println(totalArea)
+	return totalArea
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	var plan, decoded Plan
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		d, _ := s.Char("UDLR", "direction")
+		s.Literal(" ")
+		l, _ := s.Int()
+		s.Literal(" (#")
+		col := s.Col()
+		color, _ := s.Chars("0123456789abcdef", "hex digit")
+		s.Literal(")")
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if !s.Ok() {
+			continue
+		}
+		if len(color) != 6 {
+			diags = append(diags, aoc.NewDiagnostic(i+1, col, "color has %d hex digits, expected 6", len(color)))
+			continue
+		}
+		if color[5] > '3' {
+			diags = append(diags, aoc.NewDiagnostic(i+1, col+5, "encoded direction %q is not 0-3", color[5]))
+			continue
+		}
+		item := PlanItem{Direction(d), l, "(#" + color + ")"}
+		plan = append(plan, item)
+		decoded = append(decoded, item.Decode())
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+
+	for _, p := range []struct {
+		name string
+		plan Plan
+	}{{"dig plan", plan}, {"decoded dig plan", decoded}} {
+		coords := GetCoordList(p.plan)
+		if end := coords[len(coords)-1]; end != (Coord{}) {
+			diags = append(diags, aoc.NewDiagnostic(
+				len(lines), 1, "%s does not close the loop, ends at %d,%d", p.name, end.r, end.c,
+			))
+		}
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      18,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -2,21 +2,12 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Part struct {
 	x, m, a, s int
@@ -237,8 +228,7 @@ func ParseLines(lines []string) (map[string]Workflow, []Part) {
 	return workflows, parts
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	workflows, parts := ParseLines(lines)
 
 	total := 0
@@ -248,7 +238,7 @@ func part1() {
 			total += p.TotalRating()
 		}
 	}
-	println(total)
+	return total
 }
 
 type Conditions []Condition
@@ -352,20 +342,83 @@ func Solve2(workflows map[string]Workflow, start string, conditions Conditions)
 	return total
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	workflows, _ := ParseLines(lines)
 	total := Solve2(workflows, "in", Conditions{})
-	println(total)
+	return total
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	blocks, starts := aoc.Blocks(lines)
+	if len(blocks) != 2 {
+		return []aoc.Diagnostic{aoc.NewDiagnostic(1, 1, "found %d sections, expected workflows and parts", len(blocks))}
+	}
+
+	type ref struct {
+		name      string
+		line, col int
+	}
+	var diags []aoc.Diagnostic
+	defined := map[string]bool{"A": true, "R": true}
+	var refs []ref
+	for i, line := range blocks[0] {
+		num := starts[0] + i + 1
+		s := aoc.NewScanner(num, line)
+		name, _ := s.Word()
+		s.Literal("{")
+		for {
+			col := s.Col()
+			dest, _ := s.Chars(aoc.Lower+aoc.Upper, "workflow name")
+			if !s.Accept("<") && !s.Accept(">") {
+				refs = append(refs, ref{dest, num, col})
+				break
+			}
+			if len(dest) != 1 || !strings.Contains("xmas", dest) {
+				diags = append(diags, aoc.NewDiagnostic(num, col, "unknown category %q", dest))
+			}
+			s.Int()
+			s.Literal(":")
+			col = s.Col()
+			dest, _ = s.Chars(aoc.Lower+aoc.Upper, "workflow name")
+			refs = append(refs, ref{dest, num, col})
+			s.Literal(",")
+		}
+		s.Literal("}")
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if s.Ok() {
+			defined[name] = true
+		}
+	}
+	for _, r := range refs {
+		if r.name != "" && !defined[r.name] {
+			diags = append(diags, aoc.NewDiagnostic(r.line, r.col, "workflow %s is not defined", r.name))
+		}
+	}
+	if !defined["in"] {
+		diags = append(diags, aoc.NewDiagnostic(1, 1, "workflow in is not defined"))
+	}
+
+	for i, line := range blocks[1] {
+		s := aoc.NewScanner(starts[1]+i+1, line)
+		for j, prefix := range []string{"{x=", ",m=", ",a=", ",s="} {
+			s.Literal(prefix)
+			s.Int()
+			if j == 3 {
+				s.Literal("}")
+			}
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      19,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
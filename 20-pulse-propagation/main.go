@@ -2,21 +2,12 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"slices"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Pulse int
 
@@ -303,8 +294,7 @@ func (r *Registry) IsInitialState() bool {
 	return true
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	registry := NewRegistry(lines)
 
 	N := 1000
@@ -330,7 +320,7 @@ func part1() {
 		high += h
 	}
 
-	fmt.Println(low * high)
+	return low * high
 }
 
 type Hook struct {
@@ -357,8 +347,7 @@ func LCM(a, b int, integers ...int) int {
 	return result
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	registry := NewRegistry(lines)
 
 	// only &vr connects to rx
@@ -391,16 +380,42 @@ func part2() {
 		values = append(values, value)
 	}
 	lcm := LCM(values[0], values[1], values...)
-	fmt.Println(lcm)
+	return lcm
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	broadcasters := 0
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		typed := s.Accept("%") || s.Accept("&")
+		name, _ := s.Word()
+		s.Literal(" -> ")
+		s.Word()
+		for s.Accept(", ") {
+			s.Word()
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if !s.Ok() || typed {
+			continue
+		}
+		if name != "broadcaster" {
+			diags = append(diags, aoc.NewDiagnostic(i+1, 1, "module %s has no type", name))
+		}
+		broadcasters++
+	}
+	if broadcasters != 1 {
+		diags = append(diags, aoc.NewDiagnostic(1, 1, "found %d broadcasters, expected 1", broadcasters))
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      20,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -1,20 +1,10 @@
 package main
 
 import (
-	"fmt"
-	"os"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Grid struct {
 	data []string
@@ -113,15 +103,13 @@ func Solve(grid *Grid, n int) int {
 	return total
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	grid := NewGrid(lines)
 	total := Solve(grid, 64)
-	fmt.Println(total)
+	return total
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	grid := NewGrid(lines)
 
 	size := grid.h
@@ -138,16 +126,32 @@ func part2() {
 
 	target := (26501365 - half) / size
 	total := a*target*target + b*target + c
-	fmt.Println(total)
+	return total
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	diags := aoc.CheckGrid(lines, ".#S")
+	diags = append(diags, aoc.CheckCount(lines, 'S', 1)...)
+	if len(diags) > 0 {
+		return diags
+	}
+
+	// part 2 extrapolates from whole copies of the grid around the centre
+	grid := NewGrid(lines)
+	if grid.h != grid.w {
+		diags = append(diags, aoc.NewDiagnostic(1, 1, "grid is %dx%d, expected a square", grid.h, grid.w))
+	}
+	if start := grid.StartPos(); start != (Coord{grid.h / 2, grid.w / 2}) {
+		diags = append(diags, aoc.NewDiagnostic(start.r+1, start.c+1, "start is not in the centre of the grid"))
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      21,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
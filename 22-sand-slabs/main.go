@@ -2,21 +2,11 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"slices"
 	"sort"
-	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Coord struct {
 	x, y, z int
@@ -212,16 +202,14 @@ func RemoveBrick(supported, supporting map[Id][]Id, id Id) int {
 	return len(removed)
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	bricks := ParseBricks(lines)
 	StartFalling(bricks)
 	disintegrable := FindDisintegrable(bricks)
-	fmt.Println(len(disintegrable))
+	return len(disintegrable)
 }
 
-func part2() {
-	lines := ReadLines("input.txt")
+func part2(lines []string) any {
 	bricks := ParseBricks(lines)
 	StartFalling(bricks)
 
@@ -231,16 +219,47 @@ func part2() {
 	for _, brick := range bricks {
 		total += RemoveBrick(supported, supporting, brick.Id)
 	}
-	fmt.Println(total)
+	return total
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		var ends [2][3]int
+		for e := 0; e < 2; e++ {
+			if e == 1 {
+				s.Literal("~")
+			}
+			for a := 0; a < 3; a++ {
+				if a > 0 {
+					s.Literal(",")
+				}
+				ends[e][a], _ = s.Int()
+			}
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if !s.Ok() {
+			continue
+		}
+		for a, axis := range "xyz" {
+			if ends[0][a] > ends[1][a] {
+				diags = append(diags, aoc.NewDiagnostic(i+1, 1, "brick ends before it starts on %c", axis))
+			}
+		}
+		if ends[0][2] < 1 {
+			diags = append(diags, aoc.NewDiagnostic(i+1, 1, "brick is below the ground"))
+		}
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      22,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
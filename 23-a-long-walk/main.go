@@ -2,19 +2,10 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Grid []string
 
@@ -145,28 +136,36 @@ func solvePart2(grid Grid, start Coord) int {
 	return steps
 }
 
-func part1() {
-	grid := Grid(ReadLines("input.txt"))
+func part1(lines []string) any {
+	grid := Grid(lines)
 	start := findStartingPos(grid)
 	steps := solvePart1(grid, start)
-	fmt.Println(steps)
+	return steps
 }
 
-func part2() {
+func part2(lines []string) any {
 	// takes 27 minutes to run, probably have a better solution
-	grid := Grid(ReadLines("input.txt"))
+	grid := Grid(lines)
 	start := findStartingPos(grid)
 	steps := solvePart2(grid, start)
-	fmt.Println(steps)
+	return steps
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	diags := aoc.CheckGrid(lines, "#.<>^v")
+	if len(diags) > 0 {
+		return diags
+	}
+	diags = append(diags, aoc.CheckCount(lines[:1], '.', 1)...)
+	last := len(lines) - 1
+	return append(diags, aoc.ShiftDiagnostics(aoc.CheckCount(lines[last:], '.', 1), last)...)
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      23,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
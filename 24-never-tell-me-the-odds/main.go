@@ -3,19 +3,9 @@ package main
 import (
 	"fmt"
 	"math"
-	"os"
-	"strings"
-	"time"
-)
-
-func ReadFile(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 func ParseFileLine(fileLine string) (Coord, Coord) {
 	var pos, vel Coord
@@ -76,8 +66,7 @@ func InTestArea2(point Coord, start float64, end float64) bool {
 		point.y >= start && point.y <= end
 }
 
-func part1() {
-	content := ReadFile("input.txt")
+func part1(content []string) any {
 	start := 200000000000000.0
 	end := 400000000000000.0
 
@@ -103,7 +92,7 @@ func part1() {
 			}
 		}
 	}
-	fmt.Println(total)
+	return total
 }
 
 func GaussianElimination(matrix [][]float64) []float64 {
@@ -161,9 +150,7 @@ func Diff(a Coord, b Coord) Coord {
 	}
 }
 
-func part2() {
-	content := ReadFile("input.txt")
-
+func part2(content []string) any {
 	// Px Py Pz Vx Vy Vz t1 t2 t3
 	p0, v0 := ParseFileLine(content[0])
 	p1, v1 := ParseFileLine(content[1])
@@ -183,16 +170,43 @@ func part2() {
 	}
 
 	result := GaussianElimination(matrix)
-	fmt.Println(int(math.Round(result[0] + result[1] + result[2])))
+	return int(math.Round(result[0] + result[1] + result[2]))
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		var vx, col int
+		for j := 0; j < 6; j++ {
+			if j == 3 {
+				s.Spaces()
+				s.Literal("@")
+				s.Spaces()
+				col = s.Col()
+				vx, _ = s.SignedInt()
+				continue
+			}
+			if j > 0 {
+				s.Literal(",")
+				s.Spaces()
+			}
+			s.SignedInt()
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+		if s.Ok() && vx == 0 {
+			diags = append(diags, aoc.NewDiagnostic(i+1, col, "hailstone has no x velocity"))
+		}
+	}
+	return diags
 }
 
 func main() {
-	var start time.Time
-	start = time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
-
-	start = time.Now()
-	part2()
-	fmt.Println("Part 2 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      24,
+		Part1:    part1,
+		Part2:    part2,
+		Validate: validate,
+	})
 }
@@ -1,22 +1,12 @@
 package main
 
 import (
-	"fmt"
 	"math/rand"
-	"os"
 	"sort"
 	"strings"
-	"time"
-)
-
-func ReadLines(filename string) []string {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return nil
-	}
 
-	return strings.Split(strings.TrimSpace(string(content)), "\n")
-}
+	"aoc"
+)
 
 type Node string
 
@@ -188,8 +178,7 @@ func MinimumCut(g *Graph, iter int, k int) int {
 	}
 }
 
-func part1() {
-	lines := ReadLines("input.txt")
+func part1(lines []string) any {
 	g := NewGraph()
 	for _, line := range lines {
 		// line: "a: b c d"
@@ -203,11 +192,32 @@ func part1() {
 
 	r := MinimumCut(g, 500, 3)
 	nr := len(g.nodes) - r
-	fmt.Println(r * nr)
+	return r * nr
+}
+
+func validate(lines []string) []aoc.Diagnostic {
+	var diags []aoc.Diagnostic
+	for i, line := range lines {
+		s := aoc.NewScanner(i+1, line)
+		s.Word()
+		s.Literal(":")
+		for {
+			s.Literal(" ")
+			s.Word()
+			if !s.More() {
+				break
+			}
+		}
+		s.End()
+		diags = append(diags, s.Diagnostics()...)
+	}
+	return diags
 }
 
 func main() {
-	start := time.Now()
-	part1()
-	fmt.Println("Part 1 finished in:", time.Since(start))
+	aoc.Main(aoc.Puzzle{
+		Day:      25,
+		Part1:    part1,
+		Validate: validate,
+	})
 }
@@ -0,0 +1,124 @@
+package aoc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Input is one puzzle input in a batch directory, e.g. inputs/alice/17.txt
+// is alice's input for day 17.
+type Input struct {
+	Name string
+	Day  int
+	Path string
+}
+
+var inputPattern = regexp.MustCompile(`^([0-9]{2})\.txt$`)
+
+// FindInputs lists the inputs under dir, which holds one directory of
+// NN.txt files per person. They are sorted by name, then day.
+func FindInputs(dir string) ([]Input, error) {
+	people, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []Input
+	for _, person := range people {
+		if !person.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, person.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			matches := inputPattern.FindStringSubmatch(file.Name())
+			if file.IsDir() || matches == nil {
+				continue
+			}
+			n, _ := strconv.Atoi(matches[1])
+			inputs = append(inputs, Input{
+				Name: person.Name(),
+				Day:  n,
+				Path: filepath.Join(dir, person.Name(), file.Name()),
+			})
+		}
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].Name != inputs[j].Name {
+			return inputs[i].Name < inputs[j].Name
+		}
+		return inputs[i].Day < inputs[j].Day
+	})
+	return inputs, nil
+}
+
+// WriteMatrix writes batch results as a matrix with a row per day and
+// part and a column per input. JSON is the flat list of results, table
+// shows answers with their timings, and plain is tab-separated answers.
+func WriteMatrix(w io.Writer, f Format, results []Result) error {
+	if f == JSON {
+		return WriteResults(w, f, results)
+	}
+
+	type row struct{ day, part int }
+	var rows []row
+	var names []string
+	cells := make(map[row]map[string]Result)
+	for _, res := range results {
+		r := row{res.Day, res.Part}
+		if cells[r] == nil {
+			cells[r] = make(map[string]Result)
+			rows = append(rows, r)
+		}
+		cells[r][res.Input] = res
+		if !slices.Contains(names, res.Input) {
+			names = append(names, res.Input)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].day != rows[j].day {
+			return rows[i].day < rows[j].day
+		}
+		return rows[i].part < rows[j].part
+	})
+	sort.Strings(names)
+
+	var tw *tabwriter.Writer
+	if f == Table {
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		w = tw
+	}
+	fmt.Fprintf(w, "DAY\tPART\t%s\n", strings.Join(names, "\t"))
+	for _, r := range rows {
+		fmt.Fprintf(w, "%d\t%d", r.day, r.part)
+		for _, name := range names {
+			res, ok := cells[r][name]
+			switch {
+			case !ok:
+				fmt.Fprint(w, "\t-")
+			case res.Error != "":
+				fmt.Fprint(w, "\terror")
+			case f == Table:
+				fmt.Fprintf(w, "\t%s (%s)", res.Answer, res.Elapsed.Round(time.Microsecond))
+			default:
+				fmt.Fprintf(w, "\t%s", res.Answer)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	if tw != nil {
+		return tw.Flush()
+	}
+	return nil
+}
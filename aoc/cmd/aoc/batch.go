@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"aoc"
+)
+
+// batch runs every day that has an input in a directory laid out as
+// dir/<person>/<day>.txt, and prints the answers as a matrix so solvers
+// can be checked against inputs they were not written for.
+func batch(runner *aoc.Runner, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 0, "stop each part after this long, 0 for no limit")
+	parallel := fs.Int("parallel", 1, "parts allowed to run at once; above 1 timings are less reliable")
+	format := aoc.Table
+	fs.Var(&format, "format", "output `format`: plain, json or table")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: aoc batch [-timeout d] [-parallel n] [-format f] dir")
+	}
+
+	inputs, err := aoc.FindInputs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	type job struct {
+		input   aoc.Input
+		part    int
+		content []byte
+	}
+	var jobs []job
+	for _, input := range inputs {
+		day, ok := runner.Registry.Day(input.Day)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: no such day: %d\n", input.Path, input.Day)
+			continue
+		}
+		content, err := os.ReadFile(input.Path)
+		if err != nil {
+			return err
+		}
+		if err := runner.Build(day.Number); err != nil {
+			return err
+		}
+		for _, part := range day.Parts {
+			jobs = append(jobs, job{input, part, content})
+		}
+	}
+
+	results := make([]aoc.Result, len(jobs))
+	slots := make(chan struct{}, max(*parallel, 1))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, j job) {
+			defer func() { <-slots; wg.Done() }()
+			ctx, cancel := withTimeout(*timeout)
+			defer cancel()
+			results[i] = runner.Run(ctx, j.input.Day, j.part, j.content)
+			results[i].Input = j.input.Name
+		}(i, j)
+	}
+	wg.Wait()
+
+	failed := false
+	for i, res := range results {
+		if res.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s part %d: %s\n", jobs[i].input.Path, res.Part, res.Error)
+			failed = true
+		}
+	}
+	if err := aoc.WriteMatrix(os.Stdout, format, results); err != nil {
+		return err
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Command aoc runs the day programs in this repository.
+//
+//	aoc [-root dir] list
+//	aoc [-root dir] run [-input file] [-timeout d] [-format f] day [part]
+//	aoc [-root dir] validate day [file]
+//	aoc [-root dir] batch [-timeout d] [-parallel n] [-format f] dir
+//	aoc [-root dir] serve [-addr addr] [-max-input bytes] [-timeout d]
+//
+// Each day is built once per invocation and run as a subprocess.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"aoc"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+	aoc [-root dir] list
+	aoc [-root dir] run [-input file] [-timeout d] [-format f] day [part]
+	aoc [-root dir] validate day [file]
+	aoc [-root dir] batch [-timeout d] [-parallel n] [-format f] dir
+	aoc [-root dir] serve [-addr addr] [-max-input bytes] [-timeout d]`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	root := flag.String("root", "", "repository `dir`, found from the working directory by default")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := execute(*root, flag.Arg(0), flag.Args()[1:]); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(os.Stderr, "aoc:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// errFailed is returned by commands that have already reported their
+// failure on stderr.
+var errFailed = errors.New("failed")
+
+func execute(root, command string, args []string) error {
+	if root == "" {
+		var err error
+		if root, err = aoc.FindRoot(); err != nil {
+			return err
+		}
+	}
+	registry, err := aoc.NewRegistry(root)
+	if err != nil {
+		return err
+	}
+	runner, err := aoc.NewRunner(registry)
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	switch command {
+	case "list":
+		return list(registry)
+	case "run":
+		return run(runner, args)
+	case "validate":
+		return validate(runner, args)
+	case "batch":
+		return batch(runner, args)
+	case "serve":
+		return serve(runner, args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func list(registry *aoc.Registry) error {
+	for _, day := range registry.Days() {
+		fmt.Printf("%02d %s\n", day.Number, day.Name)
+	}
+	return nil
+}
+
+func parseDay(registry *aoc.Registry, arg string) (aoc.Day, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return aoc.Day{}, fmt.Errorf("invalid day %q", arg)
+	}
+	day, ok := registry.Day(n)
+	if !ok {
+		return aoc.Day{}, fmt.Errorf("no such day: %d", n)
+	}
+	return day, nil
+}
+
+func readInput(day aoc.Day, filename string) ([]byte, error) {
+	if filename == "" {
+		filename = filepath.Join(day.Dir, "input.txt")
+	}
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
+
+func run(runner *aoc.Runner, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	input := fs.String("input", "", "puzzle input `file`, - for stdin (default: the day's input.txt)")
+	timeout := fs.Duration("timeout", 0, "stop each part after this long, 0 for no limit")
+	format := aoc.Plain
+	fs.Var(&format, "format", "output `format`: plain, json or table")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errors.New("usage: aoc run [-input file] [-timeout d] [-format f] day [part]")
+	}
+
+	day, err := parseDay(runner.Registry, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	parts := day.Parts
+	if fs.NArg() == 2 {
+		part, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || !slices.Contains(day.Parts, part) {
+			return fmt.Errorf("day %d has no part %s", day.Number, fs.Arg(1))
+		}
+		parts = []int{part}
+	}
+	content, err := readInput(day, *input)
+	if err != nil {
+		return err
+	}
+	if err := runner.Build(day.Number); err != nil {
+		return err
+	}
+
+	failed := false
+	var results []aoc.Result
+	for _, part := range parts {
+		ctx, cancel := withTimeout(*timeout)
+		res := runner.Run(ctx, day.Number, part, content)
+		cancel()
+		if res.Error != "" {
+			fmt.Fprintf(os.Stderr, "day %d part %d: %s\n", day.Number, part, res.Error)
+			failed = true
+		}
+		if format == aoc.Plain {
+			aoc.WriteResults(os.Stdout, aoc.Plain, []aoc.Result{res})
+			if res.Error == "" {
+				fmt.Fprintf(os.Stderr, "Part %d finished in: %s\n", part, res.Elapsed.Round(time.Microsecond))
+			}
+		}
+		results = append(results, res)
+	}
+	if format != aoc.Plain {
+		aoc.WriteResults(os.Stdout, format, results)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func validate(runner *aoc.Runner, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: aoc validate day [file]")
+	}
+	day, err := parseDay(runner.Registry, args[0])
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(day.Dir, "input.txt")
+	if len(args) == 2 && args[1] == "-" {
+		filename = "-"
+	} else if len(args) == 2 {
+		if filename, err = filepath.Abs(args[1]); err != nil {
+			return err
+		}
+	}
+
+	cmd, err := runner.Command(context.Background(), day.Number, "-input", filename, "validate")
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return errFailed
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"aoc"
+)
+
+// server exposes the runner over a small JSON API:
+//
+//	GET  /days                      list the days and their parts
+//	POST /days/{day}/parts/{part}   solve a part, the body is the input
+type server struct {
+	runner   *aoc.Runner
+	maxInput int64
+	timeout  time.Duration
+	slots    chan struct{}
+}
+
+func serve(runner *aoc.Runner, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8023", "listen `address`")
+	maxInput := fs.Int64("max-input", 1<<20, "largest accepted input in `bytes`")
+	timeout := fs.Duration("timeout", 30*time.Second, "stop a solver after this long")
+	concurrency := fs.Int("concurrency", runtime.NumCPU(), "solvers allowed to run at once")
+	fs.Parse(args)
+
+	s := &server{
+		runner:   runner,
+		maxInput: *maxInput,
+		timeout:  *timeout,
+		slots:    make(chan struct{}, max(*concurrency, 1)),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/days", s.listDays)
+	mux.HandleFunc("/days/", s.solve)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	log.Printf("serving %d days on http://%s", len(runner.Registry.Days()), *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func (s *server) listDays(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.runner.Registry.Days())
+}
+
+func (s *server) solve(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) != 4 || segments[2] != "parts" {
+		writeError(w, http.StatusNotFound, "expected /days/{day}/parts/{part}")
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "use POST with the puzzle input as the body")
+		return
+	}
+
+	n, err := strconv.Atoi(segments[1])
+	day, ok := s.runner.Registry.Day(n)
+	if err != nil || !ok {
+		writeError(w, http.StatusNotFound, "no such day: "+segments[1])
+		return
+	}
+	part, err := strconv.Atoi(segments[3])
+	if err != nil || !slices.Contains(day.Parts, part) {
+		writeError(w, http.StatusNotFound, "no such part: "+segments[3])
+		return
+	}
+
+	input, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxInput))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "input larger than "+strconv.FormatInt(s.maxInput, 10)+" bytes")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.runner.Build(day.Number); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-r.Context().Done():
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	res := s.runner.Run(ctx, day.Number, part, input)
+
+	status := http.StatusOK
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		status = http.StatusGatewayTimeout
+	case res.Error != "":
+		status = http.StatusUnprocessableEntity
+	}
+	log.Printf("day %d part %d: %d in %s", day.Number, part, status, res.Elapsed.Round(time.Millisecond))
+	writeJSON(w, status, res)
+}
@@ -0,0 +1,77 @@
+package aoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Format selects how answers are written to stdout. Diagnostics always
+// go to stderr, whatever the format.
+type Format string
+
+const (
+	// Plain writes one answer per line and nothing else.
+	Plain Format = "plain"
+	// JSON writes an array of results, including timings and errors.
+	JSON Format = "json"
+	// Table writes aligned columns for reading in a terminal.
+	Table Format = "table"
+)
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Plain, JSON, Table:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected plain, json or table", s)
+	}
+}
+
+// String and Set let a Format be used as a flag.Value.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(s string) error {
+	parsed, err := ParseFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// WriteResults writes results to w in format f. Plain output leaves out
+// results with errors, which are expected to be reported on stderr.
+func WriteResults(w io.Writer, f Format, results []Result) error {
+	switch f {
+	case JSON:
+		if results == nil {
+			results = []Result{}
+		}
+		return json.NewEncoder(w).Encode(results)
+	case Table:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DAY\tPART\tANSWER\tTIME\tERROR")
+		for _, res := range results {
+			fmt.Fprintf(
+				tw, "%d\t%d\t%s\t%s\t%s\n",
+				res.Day, res.Part, res.Answer, res.Elapsed.Round(time.Microsecond), res.Error,
+			)
+		}
+		return tw.Flush()
+	default:
+		for _, res := range results {
+			if res.Error == "" {
+				if _, err := fmt.Fprintln(w, res.Answer); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
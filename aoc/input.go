@@ -0,0 +1,71 @@
+package aoc
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+const bom = "\ufeff"
+
+// Normalize strips a UTF-8 byte order mark, converts CRLF and lone CR
+// line endings to LF and drops trailing blank lines, so an input reads
+// the same whichever editor or platform saved it.
+func Normalize(content string) string {
+	content = strings.TrimPrefix(content, bom)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	lines := strings.Split(content, "\n")
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return strings.Join(lines[:end], "\n")
+}
+
+// Lines splits normalized content into lines. Empty content has no lines.
+func Lines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// Load reads and normalizes a whole input file, or stdin if filename
+// is "-".
+func Load(filename string) (string, error) {
+	var content []byte
+	var err error
+	if filename == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", err
+	}
+	return Normalize(string(content)), nil
+}
+
+// Open opens an input file for reading as a stream, or stdin if filename
+// is "-". Only the byte order mark is stripped; readers are expected to
+// cope with CRLF and blank lines themselves.
+func Open(filename string) (io.ReadCloser, error) {
+	f := os.Stdin
+	if filename != "-" {
+		var err error
+		if f, err = os.Open(filename); err != nil {
+			return nil, err
+		}
+	}
+	r := bufio.NewReader(f)
+	if prefix, err := r.Peek(len(bom)); err == nil && string(prefix) == bom {
+		r.Discard(len(bom))
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, nil
+}
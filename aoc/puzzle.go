@@ -0,0 +1,239 @@
+package aoc
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Solver computes the answer to one part of a puzzle from its input lines.
+type Solver func(lines []string) any
+
+// StreamSolver computes the answer to one part of a puzzle while reading
+// its input, for inputs too large to load whole.
+type StreamSolver func(r io.Reader) any
+
+// Reporter writes a human-readable explanation of how a part's answer
+// is reached. An error means the input could not be fully explained.
+type Reporter func(w io.Writer, part int, lines []string) error
+
+// Puzzle is what each day's program hands to Main. Stream1 and Stream2
+// are optional, and used instead of Part1 and Part2 when run with -stream.
+type Puzzle struct {
+	Day      int
+	Part1    Solver
+	Part2    Solver
+	Stream1  StreamSolver
+	Stream2  StreamSolver
+	Validate Validator
+	Report   Reporter
+}
+
+// Result is the outcome of solving one part of a puzzle.
+type Result struct {
+	Day     int           `json:"day"`
+	Part    int           `json:"part"`
+	Input   string        `json:"input,omitempty"`
+	Answer  string        `json:"answer,omitempty"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func (p Puzzle) Solver(part int) Solver {
+	switch part {
+	case 1:
+		return p.Part1
+	case 2:
+		return p.Part2
+	default:
+		return nil
+	}
+}
+
+func (p Puzzle) StreamSolver(part int) StreamSolver {
+	switch part {
+	case 1:
+		return p.Stream1
+	case 2:
+		return p.Stream2
+	default:
+		return nil
+	}
+}
+
+// Solve runs one part of the puzzle, turning a panic in the solver into
+// an error on the result.
+func (p Puzzle) Solve(part int, lines []string) Result {
+	solve := p.Solver(part)
+	if solve == nil {
+		return p.solve(part, nil)
+	}
+	return p.solve(part, func() any { return solve(lines) })
+}
+
+// SolveStream is Solve for a part that reads its input from r.
+func (p Puzzle) SolveStream(part int, r io.Reader) Result {
+	solve := p.StreamSolver(part)
+	if solve == nil {
+		return p.solve(part, nil)
+	}
+	return p.solve(part, func() any { return solve(r) })
+}
+
+func (p Puzzle) solve(part int, solve func() any) (res Result) {
+	res = Result{Day: p.Day, Part: part}
+	if solve == nil {
+		res.Error = fmt.Sprintf("day %d has no part %d", p.Day, part)
+		return res
+	}
+
+	start := time.Now()
+	defer func() {
+		res.Elapsed = time.Since(start)
+		if r := recover(); r != nil {
+			res.Error = fmt.Sprint(r)
+		}
+	}()
+	res.Answer = fmt.Sprint(solve())
+	return res
+}
+
+// Main is the entry point of every day's program:
+//
+//	go run . [-input file] [-part n] [-format plain|json|table] [-stream]
+//	go run . [-input file] validate
+//	go run . [-input file] [-part n] report
+//
+// The input defaults to input.txt, and "-" reads it from stdin. Answers
+// are written to stdout in the chosen format; timings (in plain format)
+// and errors go to stderr.
+func Main(p Puzzle) {
+	input := flag.String("input", "input.txt", "puzzle input `file`, - for stdin")
+	part := flag.Int("part", 0, "solve only this part")
+	stream := flag.Bool("stream", false, "read the input while solving instead of loading it first")
+	format := Plain
+	flag.Var(&format, "format", "output `format`: plain, json or table")
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "validate", "report", "":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	parts := []int{1, 2}
+	if *part != 0 {
+		parts = []int{*part}
+	}
+
+	var solve func(part int) Result
+	if *stream && flag.Arg(0) == "" {
+		if *input == "-" && len(parts) > 1 {
+			fmt.Fprintln(os.Stderr, "streaming from stdin needs -part")
+			os.Exit(2)
+		}
+		solve = func(part int) Result {
+			if p.StreamSolver(part) == nil {
+				return Result{Day: p.Day, Part: part, Error: "cannot stream this part"}
+			}
+			r, err := Open(*input)
+			if err != nil {
+				return Result{Day: p.Day, Part: part, Error: err.Error()}
+			}
+			defer r.Close()
+			return p.SolveStream(part, r)
+		}
+	} else {
+		content, err := Load(*input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		lines := Lines(content)
+		switch flag.Arg(0) {
+		case "validate":
+			os.Exit(validate(p, *input, lines))
+		case "report":
+			os.Exit(report(p, parts, lines))
+		}
+		solve = func(part int) Result {
+			return p.Solve(part, lines)
+		}
+	}
+
+	status := 0
+	var results []Result
+	for _, n := range parts {
+		if *part == 0 && p.Solver(n) == nil {
+			continue
+		}
+		res := solve(n)
+		if res.Error != "" {
+			fmt.Fprintf(os.Stderr, "part %d: %s\n", n, res.Error)
+			status = 1
+		}
+		if format == Plain {
+			// print each answer as soon as it is known
+			WriteResults(os.Stdout, Plain, []Result{res})
+			if res.Error == "" {
+				fmt.Fprintf(os.Stderr, "Part %d finished in: %s\n", n, res.Elapsed)
+			}
+		}
+		results = append(results, res)
+	}
+	if format != Plain {
+		WriteResults(os.Stdout, format, results)
+	}
+	os.Exit(status)
+}
+
+func validate(p Puzzle, filename string, lines []string) int {
+	if p.Validate == nil {
+		fmt.Fprintf(os.Stderr, "day %d has no validator\n", p.Day)
+		return 2
+	}
+
+	var diags []Diagnostic
+	if len(lines) == 0 {
+		diags = []Diagnostic{NewDiagnostic(1, 1, "empty input")}
+	} else {
+		diags = p.Validate(lines)
+	}
+	SortDiagnostics(diags)
+	for _, d := range diags {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", filename, d)
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: ok\n", filename)
+	return 0
+}
+
+func report(p Puzzle, parts []int, lines []string) int {
+	if p.Report == nil {
+		fmt.Fprintf(os.Stderr, "day %d has no report\n", p.Day)
+		return 2
+	}
+
+	status := 0
+	for i, n := range parts {
+		if p.Solver(n) == nil {
+			continue
+		}
+		if len(parts) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("Part %d\n", n)
+		}
+		if err := p.Report(os.Stdout, n, lines); err != nil {
+			fmt.Fprintf(os.Stderr, "part %d: %v\n", n, err)
+			status = 1
+		}
+	}
+	return status
+}
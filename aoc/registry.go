@@ -0,0 +1,88 @@
+package aoc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Day is a puzzle program in the repository, e.g. 01-trebuchet.
+type Day struct {
+	Number int    `json:"day"`
+	Name   string `json:"name"`
+	Parts  []int  `json:"parts"`
+	Dir    string `json:"-"`
+}
+
+// Registry lists the days found under the repository root.
+type Registry struct {
+	Root string
+	days []Day
+}
+
+var dayPattern = regexp.MustCompile(`^([0-9]{2})-(.+)$`)
+
+// FindRoot walks up from the working directory to the first directory
+// that holds day programs.
+func FindRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if matches, _ := filepath.Glob(filepath.Join(dir, "[0-9][0-9]-*", "go.mod")); len(matches) > 0 {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no day programs found in any parent directory")
+		}
+		dir = parent
+	}
+}
+
+func NewRegistry(root string) (*Registry, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{Root: root}
+	for _, entry := range entries {
+		matches := dayPattern.FindStringSubmatch(entry.Name())
+		if !entry.IsDir() || matches == nil {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(matches[1])
+		parts := []int{1, 2}
+		if n == 25 {
+			// Christmas Day only has the one puzzle
+			parts = []int{1}
+		}
+		r.days = append(r.days, Day{n, matches[2], parts, dir})
+	}
+	sort.Slice(r.days, func(i, j int) bool {
+		return r.days[i].Number < r.days[j].Number
+	})
+	return r, nil
+}
+
+func (r *Registry) Days() []Day {
+	return r.days
+}
+
+func (r *Registry) Day(n int) (Day, bool) {
+	for _, day := range r.days {
+		if day.Number == n {
+			return day, true
+		}
+	}
+	return Day{}, false
+}
@@ -0,0 +1,146 @@
+package aoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner solves puzzles by building each day's program once and running
+// it as a subprocess, so a slow or stuck solver can be stopped by
+// cancelling its context.
+type Runner struct {
+	Registry *Registry
+	binDir   string
+
+	mu     sync.Mutex
+	builds map[int]*build
+}
+
+type build struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+func NewRunner(registry *Registry) (*Runner, error) {
+	dir, err := os.MkdirTemp("", "aoc-bin-")
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{
+		Registry: registry,
+		binDir:   dir,
+		builds:   make(map[int]*build),
+	}, nil
+}
+
+// Close removes the binaries built by the runner.
+func (r *Runner) Close() error {
+	return os.RemoveAll(r.binDir)
+}
+
+func (r *Runner) binary(day Day) (string, error) {
+	r.mu.Lock()
+	b, ok := r.builds[day.Number]
+	if !ok {
+		b = &build{}
+		r.builds[day.Number] = b
+	}
+	r.mu.Unlock()
+
+	b.once.Do(func() {
+		b.path = filepath.Join(r.binDir, filepath.Base(day.Dir))
+		cmd := exec.Command("go", "build", "-o", b.path, ".")
+		cmd.Dir = day.Dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			b.err = fmt.Errorf("building day %d: %v\n%s", day.Number, err, out)
+		}
+	})
+	return b.path, b.err
+}
+
+// Build builds a day's program ahead of time, so that the build does not
+// count against the deadline of the first run.
+func (r *Runner) Build(n int) error {
+	day, ok := r.Registry.Day(n)
+	if !ok {
+		return fmt.Errorf("no such day: %d", n)
+	}
+	_, err := r.binary(day)
+	return err
+}
+
+// Command returns a command that runs a day's program with args, from
+// the day's directory. The program is built on first use.
+func (r *Runner) Command(ctx context.Context, n int, args ...string) (*exec.Cmd, error) {
+	day, ok := r.Registry.Day(n)
+	if !ok {
+		return nil, fmt.Errorf("no such day: %d", n)
+	}
+	bin, err := r.binary(day)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = day.Dir
+	return cmd, nil
+}
+
+// Run solves one part of a day against input.
+func (r *Runner) Run(ctx context.Context, n, part int, input []byte) Result {
+	res := Result{Day: n, Part: part}
+	cmd, err := r.Command(ctx, n, "-input", "-", "-part", strconv.Itoa(part), "-format", string(JSON))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+
+	// the program reports its own result, timed without process startup,
+	// unless it was killed or crashed before it could
+	var results []Result
+	if json.Unmarshal(stdout.Bytes(), &results) == nil && len(results) == 1 {
+		return results[0]
+	}
+
+	res.Elapsed = elapsed
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.Error = fmt.Sprintf("timed out after %s", elapsed.Round(time.Millisecond))
+	case ctx.Err() != nil:
+		res.Error = ctx.Err().Error()
+	case err != nil:
+		res.Error = firstLine(stderr.String())
+		if res.Error == "" {
+			res.Error = err.Error()
+		}
+	default:
+		res.Error = "no result from solver"
+	}
+	return res
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		return s[:i]
+	}
+	return s
+}
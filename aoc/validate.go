@@ -0,0 +1,259 @@
+package aoc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Digits = "0123456789"
+	Lower  = "abcdefghijklmnopqrstuvwxyz"
+	Upper  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Punct  = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// Diagnostic is a single input violation. Line and Col are 1-based.
+type Diagnostic struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func NewDiagnostic(line, col int, format string, args ...any) Diagnostic {
+	return Diagnostic{line, col, fmt.Sprintf(format, args...)}
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Col, d.Msg)
+}
+
+// Validator checks the lines of an input against a day's grammar and
+// invariants, returning every violation it finds.
+type Validator func(lines []string) []Diagnostic
+
+func SortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Col < diags[j].Col
+	})
+}
+
+// Scanner matches a single line token by token. Once a token fails to
+// match, the position is recorded and all further calls are no-ops, so
+// a validator can describe the grammar without checking every result.
+type Scanner struct {
+	line string
+	num  int
+	pos  int
+	diag *Diagnostic
+}
+
+func NewScanner(num int, line string) *Scanner {
+	return &Scanner{line: line, num: num}
+}
+
+// Col returns the 1-based column of the next unread byte.
+func (s *Scanner) Col() int {
+	return s.pos + 1
+}
+
+func (s *Scanner) Ok() bool {
+	return s.diag == nil
+}
+
+// More reports whether there is anything left to read on a line that
+// has matched so far.
+func (s *Scanner) More() bool {
+	return s.Ok() && s.pos < len(s.line)
+}
+
+func (s *Scanner) Diagnostics() []Diagnostic {
+	if s.diag == nil {
+		return nil
+	}
+	return []Diagnostic{*s.diag}
+}
+
+func (s *Scanner) fail(format string, args ...any) bool {
+	if s.diag == nil {
+		d := NewDiagnostic(s.num, s.Col(), format, args...)
+		s.diag = &d
+	}
+	return false
+}
+
+func (s *Scanner) next() string {
+	if s.pos >= len(s.line) {
+		return "end of line"
+	}
+	return strconv.Quote(s.line[s.pos : s.pos+1])
+}
+
+// Accept consumes lit if the line continues with it.
+func (s *Scanner) Accept(lit string) bool {
+	if s.Ok() && strings.HasPrefix(s.line[s.pos:], lit) {
+		s.pos += len(lit)
+		return true
+	}
+	return false
+}
+
+// Literal is like Accept but records a violation if lit is missing.
+func (s *Scanner) Literal(lit string) bool {
+	if !s.Ok() {
+		return false
+	}
+	if s.Accept(lit) {
+		return true
+	}
+	return s.fail("expected %q, found %s", lit, s.next())
+}
+
+// Chars consumes one or more bytes from set.
+func (s *Scanner) Chars(set string, what string) (string, bool) {
+	if !s.Ok() {
+		return "", false
+	}
+	start := s.pos
+	for s.pos < len(s.line) && strings.IndexByte(set, s.line[s.pos]) != -1 {
+		s.pos++
+	}
+	if s.pos == start {
+		return "", s.fail("expected %s, found %s", what, s.next())
+	}
+	return s.line[start:s.pos], true
+}
+
+// Char consumes a single byte from set.
+func (s *Scanner) Char(set string, what string) (byte, bool) {
+	if !s.Ok() {
+		return 0, false
+	}
+	if s.pos >= len(s.line) || strings.IndexByte(set, s.line[s.pos]) == -1 {
+		return 0, s.fail("expected %s, found %s", what, s.next())
+	}
+	s.pos++
+	return s.line[s.pos-1], true
+}
+
+func (s *Scanner) Spaces() bool {
+	_, ok := s.Chars(" ", "space")
+	return ok
+}
+
+func (s *Scanner) Word() (string, bool) {
+	return s.Chars(Lower, "word")
+}
+
+func (s *Scanner) Int() (int, bool) {
+	col := s.Col()
+	digits, ok := s.Chars(Digits, "number")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		s.pos = col - 1
+		return 0, s.fail("number %s out of range", digits)
+	}
+	return n, true
+}
+
+func (s *Scanner) SignedInt() (int, bool) {
+	if s.Accept("-") {
+		n, ok := s.Int()
+		return -n, ok
+	}
+	return s.Int()
+}
+
+// End records a violation if anything is left on the line.
+func (s *Scanner) End() bool {
+	if !s.Ok() {
+		return false
+	}
+	if s.pos < len(s.line) {
+		return s.fail("unexpected %s", s.next())
+	}
+	return true
+}
+
+// CheckGrid checks that lines form a non-empty rectangle whose cells are
+// all in alphabet.
+func CheckGrid(lines []string, alphabet string) []Diagnostic {
+	if len(lines) == 0 || lines[0] == "" {
+		return []Diagnostic{NewDiagnostic(1, 1, "empty grid")}
+	}
+
+	var diags []Diagnostic
+	width := len(lines[0])
+	for r, line := range lines {
+		if len(line) != width {
+			diags = append(diags, NewDiagnostic(
+				r+1, min(len(line), width)+1,
+				"row has %d columns, expected %d", len(line), width,
+			))
+		}
+		for c := 0; c < len(line); c++ {
+			if strings.IndexByte(alphabet, line[c]) == -1 {
+				diags = append(diags, NewDiagnostic(
+					r+1, c+1, "unexpected %q, expected one of %q", line[c], alphabet,
+				))
+			}
+		}
+	}
+	return diags
+}
+
+// CheckCount checks that char occurs exactly want times in lines.
+func CheckCount(lines []string, char byte, want int) []Diagnostic {
+	var found []Diagnostic
+	for r, line := range lines {
+		for c := 0; c < len(line); c++ {
+			if line[c] == char {
+				found = append(found, NewDiagnostic(r+1, c+1, "extra %q", char))
+			}
+		}
+	}
+	if len(found) == want {
+		return nil
+	}
+	if len(found) < want {
+		return []Diagnostic{NewDiagnostic(
+			1, 1, "found %d %q, expected %d", len(found), char, want,
+		)}
+	}
+	return found[want:]
+}
+
+// Blocks splits lines into groups separated by blank lines, along with
+// the index of the first line of each group.
+func Blocks(lines []string) ([][]string, []int) {
+	var blocks [][]string
+	var starts []int
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && lines[i] != "" {
+			continue
+		}
+		if i > start {
+			blocks = append(blocks, lines[start:i])
+			starts = append(starts, start)
+		}
+		start = i + 1
+	}
+	return blocks, starts
+}
+
+// ShiftDiagnostics moves diagnostics reported against a block of lines
+// to their position in the whole input.
+func ShiftDiagnostics(diags []Diagnostic, offset int) []Diagnostic {
+	for i := range diags {
+		diags[i].Line += offset
+	}
+	return diags
+}